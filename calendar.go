@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Calendar counts events by the calendar day they occur on.
+type Calendar struct {
+	counts      map[time.Time]int // count of events by midnight UTC of their date
+	first, last time.Time         // earliest and latest dates, as midnight UTC
+	location    *time.Location    // time zone dates are computed in
+}
+
+// NewCalendar returns an empty Calendar that computes the date of each event
+// in the specified location.
+func NewCalendar(location *time.Location) *Calendar {
+	return &Calendar{counts: make(map[time.Time]int), location: location}
+}
+
+// Add counts an event on the date of time t.
+func (c *Calendar) Add(t time.Time) {
+	// Dates are normalized to midnight UTC so they may be used as map keys,
+	// and so daylight saving time transitions do not change the length of
+	// any day.
+	y, m, d := t.In(c.location).Date()
+	day := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	if len(c.counts) == 0 || day.Before(c.first) {
+		c.first = day
+	}
+	if len(c.counts) == 0 || day.After(c.last) {
+		c.last = day
+	}
+	c.counts[day]++
+}
+
+// Table returns a table with a row for each date from the earliest through the
+// latest date, including dates without any events.
+func (c *Calendar) Table() *table {
+	t := newTable("Date", "Count")
+	if len(c.counts) == 0 {
+		return t
+	}
+	for day := c.first; !day.After(c.last); day = day.AddDate(0, 0, 1) {
+		count := c.counts[day]
+		t.Append(float64(count), day.Format("2006-01-02"), strconv.Itoa(count))
+	}
+	return t
+}
+
+// years returns a grid for each calendar year from the earliest through the
+// latest date, with a row for each weekday and a column for each week, in the
+// style of a wall calendar. Months are labeled above the first week which
+// starts in that month.
+func (c *Calendar) years() ([]int, []*grid) {
+	rowLabels := make([]string, 7)
+	for weekday := range rowLabels {
+		rowLabels[weekday] = weekdayLabel(weekday)
+	}
+
+	var years []int
+	var grids []*grid
+
+	for year := c.first.Year(); year <= c.last.Year(); year++ {
+		// Start on the Sunday of the week containing the first date of the
+		// year within range, and stop after the last such date.
+		start := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+		if start.Before(c.first) {
+			start = c.first
+		}
+		end := time.Date(year, 12, 31, 0, 0, 0, 0, time.UTC)
+		if end.After(c.last) {
+			end = c.last
+		}
+		sunday := start.AddDate(0, 0, -int(start.Weekday()))
+		weeks := int(end.Sub(sunday)/(7*24*time.Hour)) + 1
+
+		colLabels := make([]string, weeks)
+		g := newGrid(rowLabels, colLabels)
+		g.cellWidth = 1
+		g.gap = 0
+
+		for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+			week := int(day.Sub(sunday) / (7 * 24 * time.Hour))
+			if day.Day() == 1 || day.Equal(start) {
+				// Label the month above the first complete week in it,
+				// unless the month starts the range.
+				labelWeek := week
+				if day.Weekday() != time.Sunday && !day.Equal(start) {
+					labelWeek++
+				}
+				if labelWeek < weeks {
+					colLabels[labelWeek] = day.Month().String()[:3]
+				}
+			}
+			g.cells[day.Weekday()][week] = float64(c.counts[day])
+		}
+
+		years = append(years, year)
+		grids = append(grids, g)
+	}
+
+	return years, grids
+}
+
+// Print displays each year as a grid of shaded cells, with a row for each
+// weekday and a column for each week, followed by a legend describing the
+// count each shade represents. All years are shaded using the same scale.
+func (c *Calendar) Print(s shading) error {
+	if len(c.counts) == 0 {
+		return nil
+	}
+
+	var max float64
+	for _, count := range c.counts {
+		if max < float64(count) {
+			max = float64(count)
+		}
+	}
+
+	years, grids := c.years()
+	for i, g := range grids {
+		if _, err := fmt.Println(years[i]); err != nil {
+			return err
+		}
+		if err := g.print(s, max); err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Println(legend(s, max))
+	return err
+}
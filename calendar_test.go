@@ -0,0 +1,67 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCalendarTableIncludesEmptyDays(t *testing.T) {
+	c := NewCalendar(time.UTC)
+	c.Add(time.Date(2019, 7, 4, 12, 0, 0, 0, time.UTC))
+	c.Add(time.Date(2019, 7, 6, 12, 0, 0, 0, time.UTC))
+	c.Add(time.Date(2019, 7, 6, 23, 0, 0, 0, time.UTC))
+
+	tab := c.Table()
+
+	if got, want := len(tab.rows), 3; got != want {
+		t.Fatalf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := tab.rows[1][0], "2019-07-05"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := tab.rows[1][1], "0"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := tab.rows[2][1], "2"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestCalendarLocation(t *testing.T) {
+	c := NewCalendar(time.FixedZone("east", 3*60*60))
+	c.Add(time.Date(2019, 7, 4, 22, 0, 0, 0, time.UTC))
+
+	tab := c.Table()
+
+	if got, want := tab.rows[0][0], "2019-07-05"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestCalendarYears(t *testing.T) {
+	c := NewCalendar(time.UTC)
+	c.Add(time.Date(2019, 12, 31, 12, 0, 0, 0, time.UTC)) // Tuesday
+	c.Add(time.Date(2020, 1, 6, 12, 0, 0, 0, time.UTC))   // Monday
+
+	years, grids := c.years()
+
+	if got, want := len(grids), 2; got != want {
+		t.Fatalf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := years[1], 2020; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := grids[0].cells[time.Tuesday][0], 1.0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	// 2020 starts on a Wednesday, so the following Monday is in its second week.
+	if got, want := len(grids[1].colLabels), 2; got != want {
+		t.Fatalf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := grids[1].cells[time.Monday][1], 1.0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := grids[1].colLabels[0], "Jan"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// histogram is the set of methods shared by gohistogram.Strings and Counter,
+// allowing the program to count keys with whichever suits the options given.
+type histogram interface {
+	Add(key string)
+	FoldDuplicateKeys()
+	SortAscending()
+	SortDescending()
+	Print(width int) error
+	PrintRaw() error
+	PrintWithPercent(width int) error
+}
+
+type counterItem struct {
+	key   string
+	count int
+}
+
+// Counter is a histogram of strings which folds duplicate keys as they are
+// added, keeping keys in the order they were first seen. Whereas
+// gohistogram.Strings stores an item for each run of adjacent identical keys
+// until they are folded, a Counter only stores an item for each distinct key.
+// It also allows adding a key more than once in a single call, and allows
+// reading back the count of each key. It prints in the same layout as
+// gohistogram.Strings.
+type Counter struct {
+	items   []*counterItem
+	indexes map[string]int // index into items of each key
+	total   int            // sum of counts of all keys
+}
+
+// NewCounter returns an empty Counter.
+func NewCounter() *Counter {
+	return &Counter{indexes: make(map[string]int)}
+}
+
+// Add counts the key once.
+func (c *Counter) Add(key string) {
+	c.AddCount(key, 1)
+}
+
+// AddCount counts the key count times.
+func (c *Counter) AddCount(key string, count int) {
+	i, ok := c.indexes[key]
+	if !ok {
+		c.indexes[key] = len(c.items)
+		c.items = append(c.items, &counterItem{key: key, count: count})
+	} else {
+		c.items[i].count += count
+	}
+	c.total += count
+}
+
+// AddBytes counts the key count times. It only allocates when the key was
+// not already counted, so a buffer may be reused for each key.
+func (c *Counter) AddBytes(key []byte, count int) {
+	// The compiler does not allocate a string to look up a key.
+	if i, ok := c.indexes[string(key)]; ok {
+		c.items[i].count += count
+		c.total += count
+		return
+	}
+	c.AddCount(string(key), count)
+}
+
+// Each invokes callback with each key and its count, in order.
+func (c *Counter) Each(callback func(key string, count int)) {
+	for _, item := range c.items {
+		callback(item.key, item.count)
+	}
+}
+
+// Total returns the sum of the counts of all keys.
+func (c *Counter) Total() int { return c.total }
+
+// Scale multiplies the count of every key by factor, rounding to the nearest
+// integer, such as to estimate the counts of all lines from a sample of them.
+func (c *Counter) Scale(factor float64) {
+	c.total = 0
+	for _, item := range c.items {
+		item.count = int(math.Round(float64(item.count) * factor))
+		c.total += item.count
+	}
+}
+
+// Limit removes every key other than the top keys having the largest counts,
+// when top is not zero, along with every key whose count is less than
+// minCount, or whose percentage of the total count is less than minPercent.
+// Keys kept remain in order, and the total count is unchanged, so percentages
+// remain relative to the count of every key. When other is not empty and any
+// keys were removed, it appends a key named other whose count is the sum of
+// their counts, so the percentages shown add up to 100. It returns the number
+// of keys removed.
+func (c *Counter) Limit(top, minCount int, minPercent float64, other string) int {
+	keep := make([]bool, len(c.items))
+	for i, item := range c.items {
+		keep[i] = item.count >= minCount && 100*float64(item.count) >= minPercent*float64(c.total)
+	}
+	if top > 0 && top < len(c.items) {
+		// Among keys having equal counts, prefer those listed first.
+		order := make([]int, len(c.items))
+		for i := range order {
+			order[i] = i
+		}
+		sort.SliceStable(order, func(i, j int) bool { return c.items[order[i]].count > c.items[order[j]].count })
+		for _, i := range order[top:] {
+			keep[i] = false
+		}
+	}
+
+	var removed, count int
+	items := c.items[:0]
+	for i, item := range c.items {
+		if keep[i] {
+			items = append(items, item)
+		} else {
+			removed++
+			count += item.count
+		}
+	}
+	if removed > 0 && other != "" {
+		items = append(items, &counterItem{key: other, count: count})
+	}
+	c.items = items
+	c.indexes = make(map[string]int, len(items))
+	for i, item := range items {
+		c.indexes[item.key] = i
+	}
+	return removed
+}
+
+// FoldDuplicateKeys does nothing, because a Counter folds duplicate keys as
+// they are added.
+func (c *Counter) FoldDuplicateKeys() {}
+
+// Len returns the number of distinct keys.
+func (c *Counter) Len() int { return len(c.items) }
+
+// Less returns true when the count of key i is less than the count of key j.
+func (c *Counter) Less(i, j int) bool { return c.items[i].count < c.items[j].count }
+
+// Swap exchanges keys i and j.
+func (c *Counter) Swap(i, j int) {
+	c.items[i], c.items[j] = c.items[j], c.items[i]
+	c.indexes[c.items[i].key] = i
+	c.indexes[c.items[j].key] = j
+}
+
+// SortAscending orders keys by increasing count, preserving the order of keys
+// with equal counts.
+func (c *Counter) SortAscending() { sort.Stable(c) }
+
+// SortDescending orders keys by decreasing count, preserving the order of keys
+// with equal counts.
+func (c *Counter) SortDescending() { sort.Stable(sort.Reverse(c)) }
+
+// Table returns a table with a row for each key showing its count, and when
+// percent is true, its percentage of the total count.
+func (c *Counter) Table(percent bool) *table {
+	t := newTable("Key", "Count")
+	if percent {
+		t = newTable("Key", "Count", "Percent")
+	}
+	for _, item := range c.items {
+		cells := []string{item.key, strconv.Itoa(item.count)}
+		if percent {
+			cells = append(cells, fmt.Sprintf("% 7.2f", 100*float64(item.count)/float64(c.total)))
+		}
+		t.Append(float64(item.count), cells...)
+	}
+	return t
+}
+
+// Print displays the histogram with three columns: Key, Count, and a histogram
+// of stars.
+func (c *Counter) Print(width int) error {
+	return c.Table(false).Print(width)
+}
+
+// PrintWithPercent displays the histogram with four columns: Key, Count,
+// Percent, and a histogram of stars.
+func (c *Counter) PrintWithPercent(width int) error {
+	// Unlike its other layouts, gohistogram.Strings does not keep the bars
+	// from the final column when showing percentages. Do likewise, so the
+	// output does not depend on which of them counted the keys.
+	t := c.Table(true)
+	return t.print(t.widths(), width+1, t.barMax())
+}
+
+// PrintRaw displays the histogram with two columns: Count, and Key.
+func (c *Counter) PrintRaw() error {
+	var max int
+	for _, item := range c.items {
+		if max < item.count {
+			max = item.count
+		}
+	}
+	countLength := len(strconv.Itoa(max))
+	for _, item := range c.items {
+		if _, err := fmt.Printf("%*d %s\n", countLength, item.count, item.key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
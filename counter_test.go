@@ -0,0 +1,254 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/karrick/gohistogram"
+)
+
+func TestCounter(t *testing.T) {
+	c := NewCounter()
+	c.Add("b")
+	c.Add("a")
+	c.AddCount("b", 3)
+	c.Add("c")
+
+	if got, want := c.Len(), 3; got != want {
+		t.Fatalf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := c.Total(), 6; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+
+	var keys []string
+	var counts []int
+	c.Each(func(key string, count int) {
+		keys = append(keys, key)
+		counts = append(counts, count)
+	})
+	// first seen order
+	for i, want := range []string{"b", "a", "c"} {
+		if got := keys[i]; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	}
+	if got, want := counts[0], 4; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestCounterSortAscending(t *testing.T) {
+	c := NewCounter()
+	c.AddCount("a", 2)
+	c.AddCount("b", 1)
+	c.AddCount("c", 2)
+	c.AddCount("d", 1)
+
+	c.SortAscending()
+
+	// stable for equal counts
+	tab := c.Table(false)
+	for i, want := range []string{"b", "d", "a", "c"} {
+		if got := tab.rows[i][0]; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	}
+
+	// indexes follow keys as they move
+	c.Add("a")
+	if got, want := c.items[2].count, 3; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestCounterTablePercent(t *testing.T) {
+	c := NewCounter()
+	c.AddCount("a", 1)
+	c.AddCount("b", 3)
+
+	tab := c.Table(true)
+
+	if got, want := tab.headers[2], "Percent"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := tab.rows[1][2], "  75.00"; got != want {
+		t.Errorf("GOT: %q; WANT: %q", got, want)
+	}
+}
+
+func TestCounterScale(t *testing.T) {
+	c := NewCounter()
+	c.AddCount("a", 3)
+	c.AddCount("b", 1)
+
+	c.Scale(2.5)
+
+	if got, want := c.items[0].count, 8; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := c.items[1].count, 3; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := c.Total(), 11; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestCounterAddBytes(t *testing.T) {
+	c := NewCounter()
+	key := []byte("a")
+	c.AddBytes(key, 2)
+	key[0] = 'b' // the counter must not retain the buffer
+	c.AddBytes(key, 1)
+	c.AddBytes([]byte("a"), 1)
+
+	if got, want := c.Len(), 2; got != want {
+		t.Fatalf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := c.items[0], (counterItem{"a", 3}); *got != want {
+		t.Errorf("GOT: %v; WANT: %v", *got, want)
+	}
+	if got, want := c.Total(), 4; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if allocs := testing.AllocsPerRun(100, func() { c.AddBytes(key, 1) }); allocs != 0 {
+		t.Errorf("GOT: %v; WANT: %v", allocs, 0)
+	}
+}
+
+func TestCounterLimit(t *testing.T) {
+	newCounter := func() *Counter {
+		c := NewCounter()
+		c.AddCount("a", 1)
+		c.AddCount("b", 5)
+		c.AddCount("c", 2)
+		c.AddCount("d", 2)
+		return c
+	}
+
+	cases := []struct {
+		name       string
+		top, count int
+		percent    float64
+		other      string
+		want       []counterItem
+	}{
+		// ties prefer the key listed first, and keys remain in order
+		{"top", 2, 0, 0, "", []counterItem{{"b", 5}, {"c", 2}}},
+		{"min-count", 0, 2, 0, "", []counterItem{{"b", 5}, {"c", 2}, {"d", 2}}},
+		{"min-percent", 0, 0, 50, "", []counterItem{{"b", 5}}},
+		{"other", 1, 0, 0, "(other)", []counterItem{{"b", 5}, {"(other)", 5}}},
+		{"none removed", 10, 0, 0, "(other)", []counterItem{{"a", 1}, {"b", 5}, {"c", 2}, {"d", 2}}},
+	}
+	for _, tc := range cases {
+		c := newCounter()
+		c.Limit(tc.top, tc.count, tc.percent, tc.other)
+
+		if got, want := c.Total(), 10; got != want {
+			t.Errorf("%s: GOT: %v; WANT: %v", tc.name, got, want)
+		}
+		if got, want := c.Len(), len(tc.want); got != want {
+			t.Errorf("%s: GOT: %v; WANT: %v", tc.name, got, want)
+			continue
+		}
+		for i, want := range tc.want {
+			if got := *c.items[i]; got != want {
+				t.Errorf("%s: %d: GOT: %v; WANT: %v", tc.name, i, got, want)
+			}
+			if got := c.indexes[want.key]; got != i {
+				t.Errorf("%s: %s: GOT: %v; WANT: %v", tc.name, want.key, got, i)
+			}
+		}
+	}
+}
+
+// captureStdout returns what callback writes to standard output.
+func captureStdout(t *testing.T, callback func() error) string {
+	t.Helper()
+	return capture(t, &os.Stdout, callback)
+}
+
+// captureStderr returns what callback writes to standard error.
+func captureStderr(t *testing.T, callback func() error) string {
+	t.Helper()
+	return capture(t, &os.Stderr, callback)
+}
+
+// capture returns what callback writes to the file, which is replaced by a
+// pipe while callback runs.
+func capture(t *testing.T, file **os.File, callback func() error) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := *file
+	*file = w
+	err = callback()
+	*file = original
+	if cerr := w.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(buf)
+}
+
+func TestCounterMatchesFoldedStrings(t *testing.T) {
+	keys := []string{"abc", "def", "abc", "abc", "ghi", "def", "abc", "jklmnop", "def", "abc"}
+
+	for _, width := range []int{40, 80} {
+		sh := new(gohistogram.Strings)
+		c := NewCounter()
+		for _, key := range keys {
+			sh.Add(key)
+			c.Add(key)
+		}
+		sh.FoldDuplicateKeys()
+
+		if got, want := captureStdout(t, func() error { return c.Print(width) }), captureStdout(t, func() error { return sh.Print(width) }); got != want {
+			t.Errorf("GOT:\n%s\nWANT:\n%s", got, want)
+		}
+		if got, want := captureStdout(t, func() error { return c.PrintWithPercent(width) }), captureStdout(t, func() error { return sh.PrintWithPercent(width) }); got != want {
+			t.Errorf("GOT:\n%s\nWANT:\n%s", got, want)
+		}
+		if got, want := captureStdout(t, c.PrintRaw), captureStdout(t, sh.PrintRaw); got != want {
+			t.Errorf("GOT:\n%s\nWANT:\n%s", got, want)
+		}
+	}
+}
+
+func BenchmarkCounterSelect(b *testing.B) {
+	fs, err := NewFieldSplitter("4", "")
+	if err != nil {
+		b.Fatal(err)
+	}
+	c := NewCounter()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.Add(fs.Select(benchmarkLine))
+	}
+}
+
+func BenchmarkCounterAppendSelect(b *testing.B) {
+	fs, err := NewFieldSplitter("4", "")
+	if err != nil {
+		b.Fatal(err)
+	}
+	c := NewCounter()
+	line := []byte(benchmarkLine)
+	add := countedBytes(fs, c)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		add(line)
+	}
+}
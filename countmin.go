@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+)
+
+// countMinKind identifies a saved Count-Min sketch.
+const countMinKind = "count-min"
+
+// CountMin estimates the count of any key of a stream in fixed memory using a
+// Count-Min sketch, as described by Cormode and Muthukrishnan. Estimates never
+// underestimate the true count, and with probability of at least 1-delta,
+// overestimate it by at most epsilon times the total count. Sketches having
+// the same dimensions may be merged.
+type CountMin struct {
+	width  int     // counters in each row, which determines epsilon
+	depth  int     // rows, each using a different hash, which determines delta
+	counts [][]int // counts[row][column]
+	total  int     // sum of counts of all keys added
+}
+
+// NewCountMin returns an empty CountMin whose estimates overestimate true
+// counts by at most epsilon times the total count, with probability of at
+// least 1-delta.
+func NewCountMin(epsilon, delta float64) (*CountMin, error) {
+	if epsilon <= 0 || epsilon >= 1 {
+		return nil, fmt.Errorf("cannot use epsilon outside of the range (0, 1): %g", epsilon)
+	}
+	if delta <= 0 || delta >= 1 {
+		return nil, fmt.Errorf("cannot use delta outside of the range (0, 1): %g", delta)
+	}
+	width := int(math.Ceil(math.E / epsilon))
+	depth := int(math.Ceil(math.Log(1 / delta)))
+	return newCountMin(width, depth), nil
+}
+
+// newCountMin returns an empty CountMin having the specified dimensions.
+func newCountMin(width, depth int) *CountMin {
+	counts := make([][]int, depth)
+	for i := range counts {
+		counts[i] = make([]int, width)
+	}
+	return &CountMin{width: width, depth: depth, counts: counts}
+}
+
+// columns invokes callback with the column of the key in each row. The
+// columns are derived from two halves of a single 64-bit FNV-1a hash, as
+// described by Kirsch and Mitzenmacher, so they are the same on every
+// machine and sketches may be merged.
+func (cm *CountMin) columns(key string, callback func(row, col int)) {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	sum := h.Sum64()
+	h1, h2 := sum&0xffffffff, sum>>32
+	for row := 0; row < cm.depth; row++ {
+		callback(row, int((h1+uint64(row)*h2)%uint64(cm.width)))
+	}
+}
+
+// Add counts the key once.
+func (cm *CountMin) Add(key string) {
+	cm.AddCount(key, 1)
+}
+
+// AddCount counts the key count times.
+func (cm *CountMin) AddCount(key string, count int) {
+	cm.total += count
+	cm.columns(key, func(row, col int) {
+		cm.counts[row][col] += count
+	})
+}
+
+// Estimate returns the estimated count of the key.
+func (cm *CountMin) Estimate(key string) int {
+	estimate := -1
+	cm.columns(key, func(row, col int) {
+		if c := cm.counts[row][col]; estimate == -1 || c < estimate {
+			estimate = c
+		}
+	})
+	return estimate
+}
+
+// Total returns the sum of the counts of all keys added.
+func (cm *CountMin) Total() int { return cm.total }
+
+// Bound returns the amount by which estimates exceed true counts with
+// probability of at least 1-delta.
+func (cm *CountMin) Bound() int {
+	return int(math.Ceil(math.E / float64(cm.width) * float64(cm.total)))
+}
+
+// Merge adds the counts of other, which must have the same dimensions.
+func (cm *CountMin) Merge(other *CountMin) error {
+	if other.width != cm.width || other.depth != cm.depth {
+		return fmt.Errorf("cannot merge sketches of different dimensions: %dx%d and %dx%d", cm.depth, cm.width, other.depth, other.width)
+	}
+	for row := range cm.counts {
+		for col, c := range other.counts[row] {
+			cm.counts[row][col] += c
+		}
+	}
+	cm.total += other.total
+	return nil
+}
+
+// countMinState is the on-disk representation of a CountMin.
+type countMinState struct {
+	Version int               `json:"version"`
+	Kind    string            `json:"kind"`
+	Options map[string]string `json:"options,omitempty"` // options used when counting the keys
+	Width   int               `json:"width"`
+	Depth   int               `json:"depth"`
+	Total   int               `json:"total"`
+	Counts  [][]int           `json:"counts"`
+}
+
+// SaveCountMin writes the sketch, along with the options used to count its
+// keys, to the named file.
+func SaveCountMin(pathname string, cm *CountMin, options map[string]string) error {
+	return saveJSON(pathname, countMinState{
+		Version: stateVersion,
+		Kind:    countMinKind,
+		Options: options,
+		Width:   cm.width,
+		Depth:   cm.depth,
+		Total:   cm.total,
+		Counts:  cm.counts,
+	})
+}
+
+// LoadCountMin returns the sketch read from the named file, along with the
+// options used to count its keys.
+func LoadCountMin(pathname string) (*CountMin, map[string]string, error) {
+	var s countMinState
+	if err := loadJSON(pathname, &s); err != nil {
+		return nil, nil, err
+	}
+	if s.Version != stateVersion {
+		return nil, nil, fmt.Errorf("cannot load state: %s: unsupported version %d", pathname, s.Version)
+	}
+	if s.Kind != countMinKind {
+		return nil, nil, fmt.Errorf("cannot load state: %s: not a %s sketch", pathname, countMinKind)
+	}
+	if s.Width < 1 || s.Depth < 1 || len(s.Counts) != s.Depth {
+		return nil, nil, fmt.Errorf("cannot load state: %s: invalid dimensions", pathname)
+	}
+	for _, row := range s.Counts {
+		if len(row) != s.Width {
+			return nil, nil, fmt.Errorf("cannot load state: %s: invalid dimensions", pathname)
+		}
+	}
+	return &CountMin{width: s.Width, depth: s.Depth, counts: s.Counts, total: s.Total}, s.Options, nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestNewCountMinRejects(t *testing.T) {
+	for _, args := range [][2]float64{{0, 0.01}, {1, 0.01}, {0.01, 0}, {0.01, 1}} {
+		if _, err := NewCountMin(args[0], args[1]); err == nil {
+			t.Errorf("epsilon %g, delta %g: GOT: %v; WANT: %v", args[0], args[1], err, "error")
+		}
+	}
+}
+
+func TestCountMinEstimates(t *testing.T) {
+	cm, err := NewCountMin(0.01, 0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exact := make(map[string]int)
+	for i := 0; i < 5000; i++ {
+		key := strconv.Itoa(i % 700 % (i%7 + 1))
+		cm.Add(key)
+		exact[key]++
+	}
+
+	if got, want := cm.Total(), 5000; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	for key, actual := range exact {
+		estimate := cm.Estimate(key)
+		if estimate < actual || estimate > actual+cm.Bound() {
+			t.Errorf("%s: estimate %d outside of [%d, %d]", key, estimate, actual, actual+cm.Bound())
+		}
+	}
+	if got, want := cm.Estimate("absent"), cm.Bound(); got > want {
+		t.Errorf("GOT: %v; WANT: <= %v", got, want)
+	}
+}
+
+func TestCountMinMerge(t *testing.T) {
+	a, _ := NewCountMin(0.01, 0.01)
+	b, _ := NewCountMin(0.01, 0.01)
+	a.AddCount("x", 3)
+	b.AddCount("x", 4)
+	b.Add("y")
+
+	if err := a.Merge(b); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := a.Estimate("x"), 7; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := a.Total(), 8; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+
+	c, _ := NewCountMin(0.1, 0.01)
+	if err := a.Merge(c); err == nil {
+		t.Errorf("GOT: %v; WANT: %v", err, "error")
+	}
+}
+
+func TestCountMinRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "histogram")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	pathname := filepath.Join(dir, "monday.cms")
+
+	cm, _ := NewCountMin(0.01, 0.01)
+	cm.AddCount("a", 5)
+	if err = SaveCountMin(pathname, cm, map[string]string{"field": "3"}); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, options, err := LoadCountMin(pathname)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := options["field"], "3"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := loaded.Estimate("a"), 5; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := loaded.Total(), 5; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+
+	// Counts of keys and sketches are not interchangeable.
+	if _, err = LoadState(pathname, NewCounter()); err == nil {
+		t.Errorf("GOT: %v; WANT: %v", err, "error")
+	}
+}
+
+func TestLoadCountMinRejects(t *testing.T) {
+	dir, err := ioutil.TempDir("", "histogram")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cases := map[string]string{
+		"kind":       `{"version":1,"total":0,"keys":[]}`,
+		"version":    `{"version":2,"kind":"count-min","width":1,"depth":1,"total":0,"counts":[[0]]}`,
+		"dimensions": `{"version":1,"kind":"count-min","width":2,"depth":1,"total":0,"counts":[[0]]}`,
+	}
+	for name, contents := range cases {
+		pathname := filepath.Join(dir, name)
+		if err = ioutil.WriteFile(pathname, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err = LoadCountMin(pathname); err == nil {
+			t.Errorf("%s: GOT: %v; WANT: %v", name, err, "error")
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// crosstabPercent selects which total, if any, the cells of a cross tabulation
+// are expressed as a percentage of.
+type crosstabPercent int
+
+const (
+	percentNone crosstabPercent = iota // cells show counts
+	percentRow                         // cells show percentage of their row total
+	percentCol                         // cells show percentage of their column total
+)
+
+// Crosstab counts lines by the combination of two categorical keys, one
+// selecting the row and the other selecting the column of a contingency table.
+// Rows and columns are kept in the order their keys were first seen.
+type Crosstab struct {
+	rows, cols []string
+	rowIndex   map[string]int // index into rows of each row key
+	colIndex   map[string]int // index into cols of each column key
+	counts     [][]int        // counts[row][col], where rows may be shorter than cols
+	rowTotals  []int
+	colTotals  []int
+	total      int
+}
+
+// NewCrosstab returns an empty Crosstab.
+func NewCrosstab() *Crosstab {
+	return &Crosstab{rowIndex: make(map[string]int), colIndex: make(map[string]int)}
+}
+
+// Add counts a line having the specified row and column keys.
+func (c *Crosstab) Add(row, col string) {
+	r, ok := c.rowIndex[row]
+	if !ok {
+		r = len(c.rows)
+		c.rowIndex[row] = r
+		c.rows = append(c.rows, row)
+		c.counts = append(c.counts, nil)
+		c.rowTotals = append(c.rowTotals, 0)
+	}
+	j := c.Column(col)
+	if len(c.counts[r]) <= j {
+		// Grow the row only when needed, because columns first seen after a
+		// row was created would otherwise require growing every row.
+		grown := make([]int, len(c.cols))
+		copy(grown, c.counts[r])
+		c.counts[r] = grown
+	}
+	c.counts[r][j]++
+	c.rowTotals[r]++
+	c.colTotals[j]++
+	c.total++
+}
+
+// Column returns the index of the column key, adding a column for it when
+// needed. Columns may be added before any lines are counted in them, so they
+// appear even when they remain empty.
+func (c *Crosstab) Column(col string) int {
+	j, ok := c.colIndex[col]
+	if !ok {
+		j = len(c.cols)
+		c.colIndex[col] = j
+		c.cols = append(c.cols, col)
+		c.colTotals = append(c.colTotals, 0)
+	}
+	return j
+}
+
+// count returns the count of lines having the row and column indexes.
+func (c *Crosstab) count(r, j int) int {
+	if j < len(c.counts[r]) {
+		return c.counts[r][j]
+	}
+	return 0
+}
+
+// Grid returns a grid with a row for each row key and a column for each column
+// key, whose cells are either counts or percentages of their row or column
+// total. When totals is true, the grid also has a final column of row totals
+// and a final row of column totals, labeled "Total".
+func (c *Crosstab) Grid(totals bool, percent crosstabPercent) *grid {
+	rowLabels := append([]string(nil), c.rows...)
+	colLabels := append([]string(nil), c.cols...)
+	if totals {
+		rowLabels = append(rowLabels, "Total")
+		colLabels = append(colLabels, "Total")
+	}
+
+	// value returns the cell value of a count, given the totals of its row
+	// and column.
+	value := func(count, rowTotal, colTotal int) float64 {
+		var whole int
+		switch percent {
+		case percentRow:
+			whole = rowTotal
+		case percentCol:
+			whole = colTotal
+		default:
+			return float64(count)
+		}
+		if whole == 0 {
+			return 0
+		}
+		return 100 * float64(count) / float64(whole)
+	}
+
+	g := newGrid(rowLabels, colLabels)
+	for r := range c.rows {
+		for j := range c.cols {
+			g.cells[r][j] = value(c.count(r, j), c.rowTotals[r], c.colTotals[j])
+		}
+		if totals {
+			g.cells[r][len(c.cols)] = value(c.rowTotals[r], c.rowTotals[r], c.total)
+		}
+	}
+	if totals {
+		for j := range c.cols {
+			g.cells[len(c.rows)][j] = value(c.colTotals[j], c.total, c.colTotals[j])
+		}
+		g.cells[len(c.rows)][len(c.cols)] = value(c.total, c.total, c.total)
+	}
+	return g
+}
+
+// formatPercent returns the value formatted as a percentage with one decimal
+// place.
+func formatPercent(value float64) string {
+	return fmt.Sprintf("%.1f%%", value)
+}
+
+// formatCount returns the value formatted as an integer when it has no
+// fractional part.
+func formatCount(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
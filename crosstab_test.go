@@ -0,0 +1,118 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestCrosstabGrid(t *testing.T) {
+	c := NewCrosstab()
+	c.Add("/a", "200")
+	c.Add("/b", "404")
+	c.Add("/a", "200")
+	c.Add("/a", "500") // column first seen after its row was created
+
+	g := c.Grid(true, percentNone)
+
+	if got, want := len(g.rowLabels), 3; got != want {
+		t.Fatalf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := len(g.colLabels), 4; got != want {
+		t.Fatalf("GOT: %v; WANT: %v", got, want)
+	}
+	// first seen order
+	if got, want := g.colLabels[1], "404"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := g.rowLabels[2], "Total"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+
+	want := [][]float64{
+		{2, 0, 1, 3},
+		{0, 1, 0, 1},
+		{2, 1, 1, 4},
+	}
+	for i, row := range want {
+		for j, w := range row {
+			if got := g.cells[i][j]; got != w {
+				t.Errorf("cell %d,%d GOT: %v; WANT: %v", i, j, got, w)
+			}
+		}
+	}
+}
+
+func TestCrosstabWithoutTotals(t *testing.T) {
+	c := NewCrosstab()
+	c.Add("/a", "200")
+	c.Add("/b", "404")
+
+	g := c.Grid(false, percentNone)
+
+	if got, want := len(g.rowLabels), 2; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := len(g.colLabels), 2; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestCrosstabPercent(t *testing.T) {
+	c := NewCrosstab()
+	c.Add("/a", "200")
+	c.Add("/a", "200")
+	c.Add("/a", "200")
+	c.Add("/a", "500")
+	c.Add("/b", "200")
+
+	g := c.Grid(true, percentRow)
+
+	if got, want := g.cells[0][0], 75.0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := g.cells[0][2], 100.0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := g.cells[2][0], 80.0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+
+	g = c.Grid(true, percentCol)
+
+	if got, want := g.cells[0][0], 75.0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := g.cells[1][1], 0.0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := g.cells[0][2], 80.0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := g.cells[2][1], 100.0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestFormatPercent(t *testing.T) {
+	if got, want := formatPercent(100.0/3), "33.3%"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestCrosstabColumn(t *testing.T) {
+	c := NewCrosstab()
+	c.Column("a.log")
+	c.Column("b.log")
+	c.Add("/a", "b.log")
+
+	g := c.Grid(false, percentNone)
+
+	if got, want := len(g.colLabels), 2; got != want {
+		t.Fatalf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := g.cells[0][0], 0.0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := g.cells[0][1], 1.0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Cyclical counts events by the slot of a recurring period they fall in, such
+// as the hour of the day or the day of the week, rather than by their absolute
+// time.
+type Cyclical struct {
+	kind        string         // one of "hour", "weekday", or "week"
+	counts      [7][24]int     // counts[weekday][hour]
+	first, last time.Time      // earliest and latest times added
+	location    *time.Location // time zone slots are computed in
+}
+
+// NewCyclical returns a Cyclical that folds times into slots of the specified
+// kind: "hour" for the 24 hours of the day, "weekday" for the 7 days of the
+// week, or "week" for the 7 by 24 grid of each hour of each weekday.
+func NewCyclical(kind string, location *time.Location) (*Cyclical, error) {
+	switch kind {
+	case "hour", "weekday", "week":
+		return &Cyclical{kind: kind, location: location}, nil
+	}
+	return nil, fmt.Errorf("cannot use cycle other than hour, weekday, or week: %q", kind)
+}
+
+// Add counts the slot the specified time falls in.
+func (c *Cyclical) Add(t time.Time) {
+	t = t.In(c.location)
+	c.counts[t.Weekday()][t.Hour()]++
+	if c.first.IsZero() || t.Before(c.first) {
+		c.first = t
+	}
+	if c.last.IsZero() || t.After(c.last) {
+		c.last = t
+	}
+}
+
+// days returns the number of times each weekday occurs in the calendar days
+// from the earliest through the latest time added, inclusive.
+func (c *Cyclical) days() [7]int {
+	var days [7]int
+	if c.first.IsZero() {
+		return days
+	}
+	// Compare dates in UTC so daylight saving time transitions do not change
+	// the length of any day.
+	y, m, d := c.first.Date()
+	first := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	y, m, d = c.last.Date()
+	last := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+
+	n := int(last.Sub(first)/(24*time.Hour)) + 1
+	for i := range days {
+		days[i] = n / 7
+	}
+	for i := 0; i < n%7; i++ {
+		days[(int(first.Weekday())+i)%7]++
+	}
+	return days
+}
+
+// Table returns a table with a row for each slot in natural order, including
+// slots without any events. When average is true, each row also shows the
+// mean count per day covered by the input, which then drives the bars.
+func (c *Cyclical) Table(average bool) *table {
+	days := c.days()
+
+	var t *table
+	if average {
+		t = newTable(c.kindTitle(), "Count", "Average")
+	} else {
+		t = newTable(c.kindTitle(), "Count")
+	}
+
+	appendRow := func(label string, count, days int) {
+		if !average {
+			t.Append(float64(count), label, strconv.Itoa(count))
+			return
+		}
+		var mean float64
+		if days > 0 {
+			mean = float64(count) / float64(days)
+		}
+		t.Append(mean, label, strconv.Itoa(count), strconv.FormatFloat(mean, 'f', 2, 64))
+	}
+
+	switch c.kind {
+	case "hour":
+		var total int
+		for _, d := range days {
+			total += d
+		}
+		for hour := 0; hour < 24; hour++ {
+			var count int
+			for weekday := range c.counts {
+				count += c.counts[weekday][hour]
+			}
+			appendRow(hourLabel(hour), count, total)
+		}
+	case "weekday":
+		for weekday, hours := range c.counts {
+			var count int
+			for _, n := range hours {
+				count += n
+			}
+			appendRow(weekdayLabel(weekday), count, days[weekday])
+		}
+	}
+	return t
+}
+
+// Grid returns a grid with a row for each weekday and a column for each hour.
+// When average is true, each cell holds the mean count for that hour across
+// the number of times its weekday occurs in the input.
+func (c *Cyclical) Grid(average bool) *grid {
+	rowLabels := make([]string, 7)
+	for weekday := range rowLabels {
+		rowLabels[weekday] = weekdayLabel(weekday)
+	}
+	colLabels := make([]string, 24)
+	for hour := range colLabels {
+		colLabels[hour] = hourLabel(hour)
+	}
+
+	g := newGrid(rowLabels, colLabels)
+	days := c.days()
+	for weekday, hours := range c.counts {
+		for hour, count := range hours {
+			v := float64(count)
+			if average {
+				if days[weekday] == 0 {
+					continue
+				}
+				v /= float64(days[weekday])
+			}
+			g.cells[weekday][hour] = v
+		}
+	}
+	return g
+}
+
+// kindTitle returns the header of the key column.
+func (c *Cyclical) kindTitle() string {
+	if c.kind == "hour" {
+		return "Hour"
+	}
+	return "Weekday"
+}
+
+func hourLabel(hour int) string { return fmt.Sprintf("%02d", hour) }
+
+func weekdayLabel(weekday int) string { return time.Weekday(weekday).String()[:3] }
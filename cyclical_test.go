@@ -0,0 +1,95 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCyclicalInvalidKind(t *testing.T) {
+	_, err := NewCyclical("month", time.UTC)
+	if err == nil {
+		t.Errorf("GOT: %v; WANT: %v", err, "non-nil")
+	}
+}
+
+func TestCyclicalHourIncludesEmptySlots(t *testing.T) {
+	c, err := NewCyclical("hour", time.UTC)
+	if err != nil {
+		t.Fatalf("GOT: %v; WANT: %v", err, nil)
+	}
+
+	c.Add(time.Date(2019, 7, 4, 13, 5, 0, 0, time.UTC))
+	c.Add(time.Date(2019, 7, 5, 13, 55, 0, 0, time.UTC))
+	c.Add(time.Date(2019, 7, 5, 2, 0, 0, 0, time.UTC))
+
+	tab := c.Table(true)
+
+	if got, want := len(tab.rows), 24; got != want {
+		t.Fatalf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := tab.rows[0][0], "00"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := tab.rows[0][1], "0"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := tab.rows[13][1], "2"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	// two events over two days
+	if got, want := tab.rows[13][2], "1.00"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := tab.rows[2][2], "0.50"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestCyclicalWeekdayAverage(t *testing.T) {
+	c, err := NewCyclical("weekday", time.UTC)
+	if err != nil {
+		t.Fatalf("GOT: %v; WANT: %v", err, nil)
+	}
+
+	// Monday 2019-07-01 through Monday 2019-07-15 covers three Mondays.
+	c.Add(time.Date(2019, 7, 1, 10, 0, 0, 0, time.UTC))
+	c.Add(time.Date(2019, 7, 8, 10, 0, 0, 0, time.UTC))
+	c.Add(time.Date(2019, 7, 15, 10, 0, 0, 0, time.UTC))
+	c.Add(time.Date(2019, 7, 2, 10, 0, 0, 0, time.UTC))
+
+	tab := c.Table(true)
+
+	if got, want := len(tab.rows), 7; got != want {
+		t.Fatalf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := tab.rows[time.Monday][0], "Mon"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := tab.rows[time.Monday][2], "1.00"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := tab.rows[time.Tuesday][2], "0.50"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestCyclicalGrid(t *testing.T) {
+	c, err := NewCyclical("week", time.UTC)
+	if err != nil {
+		t.Fatalf("GOT: %v; WANT: %v", err, nil)
+	}
+
+	c.Add(time.Date(2019, 7, 4, 13, 5, 0, 0, time.UTC)) // Thursday
+
+	g := c.Grid(false)
+
+	if got, want := len(g.cells), 7; got != want {
+		t.Fatalf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := len(g.cells[0]), 24; got != want {
+		t.Fatalf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := g.cells[time.Thursday][13], 1.0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Diff counts each key separately in two inputs, A and B, in order to compare
+// them. Keys are kept in the order they were first seen in either input.
+type Diff struct {
+	keys    []string
+	counts  [][2]int       // count of each key in A and in B
+	indexes map[string]int // index into keys of each key
+}
+
+// NewDiff returns an empty Diff.
+func NewDiff() *Diff {
+	return &Diff{indexes: make(map[string]int)}
+}
+
+// Add counts the key in input A when b is false, or in input B when b is true.
+func (d *Diff) Add(key string, b bool) {
+	i, ok := d.indexes[key]
+	if !ok {
+		i = len(d.keys)
+		d.indexes[key] = i
+		d.keys = append(d.keys, key)
+		d.counts = append(d.counts, [2]int{})
+	}
+	if b {
+		d.counts[i][1]++
+	} else {
+		d.counts[i][0]++
+	}
+}
+
+// Table returns a table with a row for each key showing its count in each
+// input, the change from A to B, and that change relative to the count in A.
+// Keys only in B are marked "new", and keys only in A are marked "gone". The
+// change drives the bar of each row, which is negative for keys less common
+// in B. Columns of counts are headed by the names of the inputs.
+func (d *Diff) Table(nameA, nameB string) *table {
+	t := newTable("Key", nameA, nameB, "Change", "Relative")
+	for i, key := range d.keys {
+		a, b := d.counts[i][0], d.counts[i][1]
+		var relative string
+		switch {
+		case a == 0:
+			relative = "new"
+		case b == 0:
+			relative = "gone"
+		default:
+			relative = fmt.Sprintf("%+.1f%%", 100*float64(b-a)/float64(a))
+		}
+		t.Append(float64(b-a), key, strconv.Itoa(a), strconv.Itoa(b), fmt.Sprintf("%+d", b-a), relative)
+	}
+	return t
+}
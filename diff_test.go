@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestDiffTable(t *testing.T) {
+	d := NewDiff()
+	d.Add("a", false)
+	d.Add("a", false)
+	d.Add("b", true)
+	d.Add("a", true)
+	d.Add("c", false)
+
+	tab := d.Table("before", "after")
+
+	if got, want := tab.headers[1], "before"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	want := [][]string{
+		{"a", "2", "1", "-1", "-50.0%"},
+		{"b", "0", "1", "+1", "new"},
+		{"c", "1", "0", "-1", "gone"},
+	}
+	if got, want := len(tab.rows), len(want); got != want {
+		t.Fatalf("GOT: %v; WANT: %v", got, want)
+	}
+	for i, row := range want {
+		for j, w := range row {
+			if got := tab.rows[i][j]; got != w {
+				t.Errorf("row %d cell %d GOT: %v; WANT: %v", i, j, got, w)
+			}
+		}
+	}
+	if got, want := tab.bars[0], -1.0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
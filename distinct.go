@@ -0,0 +1,63 @@
+package main
+
+import (
+	"strconv"
+)
+
+// distinctCounter counts the distinct values of a stream, either exactly or
+// by estimating them.
+type distinctCounter interface {
+	Add(value string)
+	Count() int
+}
+
+// Distinct counts the number of lines and the number of distinct values of
+// each key, such as the unique users of each endpoint. Keys are kept in the
+// order they were first seen.
+type Distinct struct {
+	exact    bool
+	keys     []string
+	lines    []int
+	counters []distinctCounter
+	indexes  map[string]int // index into keys of each key
+}
+
+// NewDistinct returns an empty Distinct which counts the distinct values of
+// each key exactly when exact is true, otherwise estimates them in fixed
+// memory per key using HyperLogLog.
+func NewDistinct(exact bool) *Distinct {
+	return &Distinct{exact: exact, indexes: make(map[string]int)}
+}
+
+// Add counts the line having the key and value.
+func (d *Distinct) Add(key, value string) {
+	i, ok := d.indexes[key]
+	if !ok {
+		i = len(d.keys)
+		d.indexes[key] = i
+		d.keys = append(d.keys, key)
+		d.lines = append(d.lines, 0)
+		if d.exact {
+			d.counters = append(d.counters, make(valueSet))
+		} else {
+			d.counters = append(d.counters, NewHyperLogLog(hllPrecision))
+		}
+	}
+	d.lines[i]++
+	d.counters[i].Add(value)
+}
+
+// Table returns a table with a row for each key showing its number of lines
+// and number of distinct values, which drives the bar of each row.
+func (d *Distinct) Table() *table {
+	t := newTable("Key", "Lines", "Distinct")
+	for i, key := range d.keys {
+		count := d.counters[i].Count()
+		if count > d.lines[i] {
+			// An estimate can never exceed the number of values seen.
+			count = d.lines[i]
+		}
+		t.Append(float64(count), key, strconv.Itoa(d.lines[i]), strconv.Itoa(count))
+	}
+	return t
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestDistinctExact(t *testing.T) {
+	d := NewDistinct(true)
+	d.Add("/b", "alice")
+	d.Add("/a", "alice")
+	d.Add("/b", "bob")
+	d.Add("/b", "alice")
+
+	tab := d.Table()
+
+	if got, want := len(tab.rows), 2; got != want {
+		t.Fatalf("GOT: %v; WANT: %v", got, want)
+	}
+	// first seen order
+	if got, want := tab.rows[0][0], "/b"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := tab.rows[0][1], "3"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := tab.rows[0][2], "2"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := tab.bars[0], 2.0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestDistinctEstimateNeverExceedsLines(t *testing.T) {
+	d := NewDistinct(false)
+	d.Add("/a", "alice")
+
+	tab := d.Table()
+
+	if got, want := tab.rows[0][2], "1"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
@@ -1,9 +1,12 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
 	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 // FieldSplitter parses strings into zero, one, or more fields according to the
@@ -24,14 +27,23 @@ import (
 type FieldSplitter struct {
 	fieldDelimiter     string                    // used to split string. when empty, splits on whitespace
 	fps                []func([]string) []string // field picker functions
+	ranges             []fieldRange              // fields picked by each field picker function, used by AppendSelect
 	fieldCountEstimate int                       // estimate number of fields each Fields() method will return
 }
 
+// fieldRange is the range of zero based field indexes picked by a field
+// specification, from low up to but excluding high. High is -1 for open ended
+// ranges.
+type fieldRange struct {
+	low, high int
+}
+
 // NewFieldSplitter returns a FieldSplitter.
 func NewFieldSplitter(commaDelimitedSpecs, fieldDelimiter string) (*FieldSplitter, error) {
 	fs := &FieldSplitter{fieldDelimiter: fieldDelimiter, fieldCountEstimate: 1}
 
 	if commaDelimitedSpecs == "" {
+		fs.ranges = []fieldRange{{0, -1}}
 		return fs, nil
 	}
 
@@ -40,6 +52,7 @@ func NewFieldSplitter(commaDelimitedSpecs, fieldDelimiter string) (*FieldSplitte
 	specs := strings.Split(commaDelimitedSpecs, ",")
 
 	fs.fps = make([]func([]string) []string, len(specs)) // we know exactly how many functions to call
+	fs.ranges = make([]fieldRange, len(specs))
 
 	for i, spec := range specs {
 		// Because no such thing as a negative field number, when first byte is
@@ -53,6 +66,7 @@ func NewFieldSplitter(commaDelimitedSpecs, fieldDelimiter string) (*FieldSplitte
 					}
 					return []string{ss[someInt-1]}
 				}
+				fs.ranges[i] = fieldRange{someInt - 1, someInt}
 				fs.fieldCountEstimate++
 				continue // next field specification
 			}
@@ -100,6 +114,7 @@ func NewFieldSplitter(commaDelimitedSpecs, fieldDelimiter string) (*FieldSplitte
 				}
 				return ss[left-1:]
 			}
+			fs.ranges[i] = fieldRange{left - 1, -1}
 			fs.fieldCountEstimate += left
 		} else if left == -1 {
 			// -R
@@ -109,6 +124,7 @@ func NewFieldSplitter(commaDelimitedSpecs, fieldDelimiter string) (*FieldSplitte
 				}
 				return ss[:right]
 			}
+			fs.ranges[i] = fieldRange{0, right}
 			// Expect at least one field, which is not entirely accurate,
 			// because field specification could be "5-", and there might be 10
 			// fields in a particular input string.
@@ -118,8 +134,15 @@ func NewFieldSplitter(commaDelimitedSpecs, fieldDelimiter string) (*FieldSplitte
 		} else {
 			// L-R
 			fs.fps[i] = func(ss []string) []string {
+				if len(ss) < left {
+					return nil
+				}
+				if len(ss) < right {
+					return ss[left-1:]
+				}
 				return ss[left-1 : right]
 			}
+			fs.ranges[i] = fieldRange{left - 1, right}
 			fs.fieldCountEstimate += 1 + right - left
 		}
 	}
@@ -165,3 +188,83 @@ func (fs *FieldSplitter) Select(s string) string {
 	}
 	return strings.Join(fs.Fields(s), " ")
 }
+
+// AppendSelect appends to dst the selected fields of line, joined by the field
+// delimiter, and returns the extended buffer. It selects the same fields as
+// Select, but rather than splitting the entire line into a slice of strings
+// then joining the selected fields into a new string, it scans the line only
+// as far as the last field selected, and does not allocate when dst has
+// sufficient capacity.
+func (fs *FieldSplitter) AppendSelect(dst, line []byte) []byte {
+	separator := fs.fieldDelimiter
+	if separator == "" {
+		separator = " "
+	}
+	var appended bool
+	for _, r := range fs.ranges {
+		position := 0
+		for index := 0; r.high < 0 || index < r.high; index++ {
+			start, end, next, ok := fs.nextField(line, position)
+			if !ok {
+				break
+			}
+			if index >= r.low {
+				if appended {
+					dst = append(dst, separator...)
+				}
+				dst = append(dst, line[start:end]...)
+				appended = true
+			}
+			position = next
+		}
+	}
+	return dst
+}
+
+// nextField returns the offsets of the first field of line at or after
+// position, and the position following it, splitting fields as Fields does.
+// It returns false when there are no more fields.
+func (fs *FieldSplitter) nextField(line []byte, position int) (int, int, int, bool) {
+	if fs.fieldDelimiter != "" {
+		// Like strings.Split, every delimiter ends a field, even an empty
+		// one, and the final field follows the final delimiter.
+		if position > len(line) {
+			return 0, 0, 0, false
+		}
+		if i := bytes.Index(line[position:], []byte(fs.fieldDelimiter)); i >= 0 {
+			return position, position + i, position + i + len(fs.fieldDelimiter), true
+		}
+		return position, len(line), len(line) + 1, true
+	}
+
+	// Like strings.Fields, fields are separated by runs of white space.
+	for position < len(line) {
+		space, size := spaceAt(line, position)
+		if !space {
+			break
+		}
+		position += size
+	}
+	if position == len(line) {
+		return 0, 0, 0, false
+	}
+	end := position
+	for end < len(line) {
+		space, size := spaceAt(line, end)
+		if space {
+			break
+		}
+		end += size
+	}
+	return position, end, end, true
+}
+
+// spaceAt returns whether the character at position of line is white space as
+// defined by unicode.IsSpace, along with its size in bytes.
+func spaceAt(line []byte, position int) (bool, int) {
+	if c := line[position]; c < utf8.RuneSelf {
+		return c == ' ' || c == '\t' || c == '\n' || c == '\v' || c == '\f' || c == '\r', 1
+	}
+	r, size := utf8.DecodeRune(line[position:])
+	return unicode.IsSpace(r), size
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -223,3 +224,104 @@ func TestTextFieldsRightIndexExact(t *testing.T) {
 		t.Fatalf("GOT: %v; WANT: %v", got, want)
 	}
 }
+
+func TestAppendSelectMatchesSelect(t *testing.T) {
+	lines := []string{
+		"one two three four five six seven eight nine ten",
+		"  leading and trailing white space  ",
+		"tabs\tand\v\fother white space",
+		"invalid \xff utf-8",
+		"one",
+		"a,b,,d,",
+		",",
+	}
+	for _, delimiter := range []string{"", ",", "  "} {
+		for _, specs := range []string{"", "1", "2", "3,1", "2-", "-2", "2-4", "4-20", "12"} {
+			fs, err := NewFieldSplitter(specs, delimiter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, line := range lines {
+				got := string(fs.AppendSelect(nil, []byte(line)))
+				if want := fs.Select(line); got != want {
+					t.Errorf("%q %q %q: GOT: %q; WANT: %q", delimiter, specs, line, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestAppendSelectReusesBuffer(t *testing.T) {
+	fs, err := NewFieldSplitter("2,4", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 0, 64)
+	line := []byte("one two three four five")
+
+	allocs := testing.AllocsPerRun(100, func() {
+		buf = fs.AppendSelect(buf[:0], line)
+	})
+
+	if got, want := string(buf), "two four"; got != want {
+		t.Errorf("GOT: %q; WANT: %q", got, want)
+	}
+	if got, want := allocs, 0.0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+var benchmarkLine = "2019-07-04T00:00:01Z web1 GET /api/v1/users/42 200 0.023 Mozilla/5.0"
+
+func BenchmarkFieldSplitterSelect(b *testing.B) {
+	fs, err := NewFieldSplitter("4", "")
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = fs.Select(benchmarkLine)
+	}
+}
+
+func BenchmarkFieldSplitterAppendSelect(b *testing.B) {
+	fs, err := NewFieldSplitter("4", "")
+	if err != nil {
+		b.Fatal(err)
+	}
+	line := []byte(benchmarkLine)
+	var buf []byte
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buf = fs.AppendSelect(buf[:0], line)
+	}
+}
+
+func BenchmarkFieldSplitterSelectDelimiter(b *testing.B) {
+	fs, err := NewFieldSplitter("2-3", ",")
+	if err != nil {
+		b.Fatal(err)
+	}
+	line := strings.Replace(benchmarkLine, " ", ",", -1)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = fs.Select(line)
+	}
+}
+
+func BenchmarkFieldSplitterAppendSelectDelimiter(b *testing.B) {
+	fs, err := NewFieldSplitter("2-3", ",")
+	if err != nil {
+		b.Fatal(err)
+	}
+	line := []byte(strings.Replace(benchmarkLine, " ", ",", -1))
+	var buf []byte
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buf = fs.AppendSelect(buf[:0], line)
+	}
+}
@@ -6,7 +6,6 @@ require (
 	github.com/karrick/gobls v1.3.5
 	github.com/karrick/gohistogram v0.3.1
 	github.com/karrick/golf v1.4.0
-	github.com/karrick/gorill v1.10.3
 	github.com/karrick/gows v0.3.0
 	golang.org/x/sys v0.0.0-20220319134239-a9b59b0215f8 // indirect
 )
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// group is the count of each key among the lines of a single group.
+type group struct {
+	name   string
+	keys   []string       // keys in the order they were first seen
+	counts map[string]int // count of each key
+	total  int            // count of all keys
+}
+
+// Groups counts keys separately for each group they belong to, so the
+// distribution of keys within each group may be displayed on its own. Groups
+// are kept in the order they were first seen.
+type Groups struct {
+	groups  []*group
+	indexes map[string]int // index into groups of each group name
+}
+
+// NewGroups returns an empty Groups.
+func NewGroups() *Groups {
+	return &Groups{indexes: make(map[string]int)}
+}
+
+// Add counts the key within the named group.
+func (gs *Groups) Add(name, key string) {
+	i, ok := gs.indexes[name]
+	if !ok {
+		i = len(gs.groups)
+		gs.indexes[name] = i
+		gs.groups = append(gs.groups, &group{name: name, counts: make(map[string]int)})
+	}
+	g := gs.groups[i]
+	if _, ok := g.counts[key]; !ok {
+		g.keys = append(g.keys, key)
+	}
+	g.counts[key]++
+	g.total++
+}
+
+// Tables returns a table for each group, with a row for each key of the group
+// showing its count. When top is positive, tables only include the top keys
+// having the largest counts.
+func (gs *Groups) Tables(top int) []*table {
+	tables := make([]*table, len(gs.groups))
+	for i, g := range gs.groups {
+		t := newTable("Key", "Count")
+		for _, key := range g.keys {
+			count := g.counts[key]
+			t.Append(float64(count), key, strconv.Itoa(count))
+		}
+		if top > 0 {
+			t.Top(top)
+		}
+		tables[i] = t
+	}
+	return tables
+}
+
+// Titles returns a title for each group, naming the group along with its
+// number of lines and keys, and noting when only its top keys are shown.
+func (gs *Groups) Titles(top int) []string {
+	titles := make([]string, len(gs.groups))
+	for i, g := range gs.groups {
+		titles[i] = fmt.Sprintf("%s: %d lines, %d keys", g.name, g.total, len(g.keys))
+		if top > 0 && top < len(g.keys) {
+			titles[i] += fmt.Sprintf(", top %d shown", top)
+		}
+	}
+	return titles
+}
+
+// Names returns the name of each group.
+func (gs *Groups) Names() []string {
+	names := make([]string, len(gs.groups))
+	for i, g := range gs.groups {
+		names[i] = g.name
+	}
+	return names
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestGroups(t *testing.T) {
+	gs := NewGroups()
+	gs.Add("web2", "disk full")
+	gs.Add("web1", "timeout")
+	gs.Add("web2", "timeout")
+	gs.Add("web2", "disk full")
+
+	names := gs.Names()
+	tables := gs.Tables(0)
+
+	if got, want := len(tables), 2; got != want {
+		t.Fatalf("GOT: %v; WANT: %v", got, want)
+	}
+	// first seen order
+	if got, want := names[0], "web2"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := len(tables[0].rows), 2; got != want {
+		t.Fatalf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := tables[0].rows[0][0], "disk full"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := tables[0].rows[0][1], "2"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := gs.Titles(0)[0], "web2: 3 lines, 2 keys"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestGroupsTop(t *testing.T) {
+	gs := NewGroups()
+	gs.Add("web1", "a")
+	gs.Add("web1", "b")
+	gs.Add("web1", "c")
+	gs.Add("web1", "c")
+
+	tables := gs.Tables(2)
+
+	if got, want := len(tables[0].rows), 2; got != want {
+		t.Fatalf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := tables[0].rows[1][0], "c"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := gs.Titles(2)[0], "web1: 4 lines, 3 keys, top 2 shown"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"math"
+	"time"
+)
+
+// heatmapBuckets are the widths of time buckets considered when a Heatmap is
+// fit to a number of columns, in increasing order.
+var heatmapBuckets = []time.Duration{
+	time.Minute,
+	2 * time.Minute,
+	5 * time.Minute,
+	10 * time.Minute,
+	15 * time.Minute,
+	30 * time.Minute,
+	time.Hour,
+	2 * time.Hour,
+	3 * time.Hour,
+	6 * time.Hour,
+	12 * time.Hour,
+	24 * time.Hour,
+	7 * 24 * time.Hour,
+}
+
+// rawHeatmapColumns is the most buckets of time chosen automatically for raw
+// output, which has no width to fit.
+const rawHeatmapColumns = 80
+
+// Heatmap counts numeric values in logarithmically sized bins for each bucket
+// of time, in order to show how the distribution of values changes over time.
+//
+// Buckets are aligned to the wall clock of the Heatmap's location, rather
+// than to UTC, so that buckets of an hour or a day start on the local hour or
+// at local midnight.
+type Heatmap struct {
+	bucket   time.Duration      // width of each bucket of time
+	location *time.Location     // time zone buckets are aligned to
+	buckets  map[int64]*LogBins // values in each bucket, by number of buckets since the epoch
+	min, max int64              // earliest and latest bucket having a value
+}
+
+// NewHeatmap returns an empty Heatmap having time buckets of the specified
+// width, aligned to the wall clock of location.
+func NewHeatmap(bucket time.Duration, location *time.Location) *Heatmap {
+	return &Heatmap{bucket: bucket, location: location, buckets: make(map[int64]*LogBins)}
+}
+
+// Add counts the value in the bucket of time t. NaN and infinite values are
+// ignored.
+func (h *Heatmap) Add(t time.Time, value float64) {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return
+	}
+	_, offset := t.In(h.location).Zone()
+	h.bins(h.index(t.UnixNano() + int64(offset)*int64(time.Second))).Add(value)
+}
+
+// bins returns the bins of the specified bucket, creating them when needed.
+func (h *Heatmap) bins(b int64) *LogBins {
+	lb, ok := h.buckets[b]
+	if !ok {
+		lb = NewLogBins()
+		h.buckets[b] = lb
+		if len(h.buckets) == 1 || b < h.min {
+			h.min = b
+		}
+		if len(h.buckets) == 1 || b > h.max {
+			h.max = b
+		}
+	}
+	return lb
+}
+
+// index returns the number of buckets between the epoch and the bucket of the
+// wall clock time expressed in nanoseconds since the epoch.
+func (h *Heatmap) index(wall int64) int64 {
+	b := wall / int64(h.bucket)
+	if wall < 0 && wall%int64(h.bucket) != 0 {
+		b-- // round towards negative infinity
+	}
+	return b
+}
+
+// start returns the wall clock time at the start of the bucket, expressed as
+// a UTC time so it formats as the wall clock time.
+func (h *Heatmap) start(b int64) time.Time {
+	return time.Unix(0, b*int64(h.bucket)).UTC()
+}
+
+// Fit returns a Heatmap having the smallest bucket from heatmapBuckets, which
+// must be a multiple of the bucket of h, such that all buckets from the
+// earliest through the latest fit within the specified number of columns.
+// When no candidate fits, the largest is used.
+func (h *Heatmap) Fit(columns int) *Heatmap {
+	var bucket time.Duration
+	for _, bucket = range heatmapBuckets {
+		if bucket < h.bucket || bucket%h.bucket != 0 {
+			continue
+		}
+		factor := int64(bucket / h.bucket)
+		if int((h.max/factor)-(h.min/factor)) < columns {
+			break
+		}
+	}
+	return h.Rebucket(bucket)
+}
+
+// Rebucket returns a Heatmap with the counts of h combined into buckets of the
+// specified width, which must be a multiple of the bucket of h.
+func (h *Heatmap) Rebucket(bucket time.Duration) *Heatmap {
+	if bucket == h.bucket {
+		return h
+	}
+	other := NewHeatmap(bucket, h.location)
+	for b, lb := range h.buckets {
+		other.bins(other.index(b * int64(h.bucket))).Merge(lb)
+	}
+	return other
+}
+
+// Grid returns a grid with a column for each bucket of time from the earliest
+// through the latest, and a row for each bin of values from the largest at the
+// top through the smallest at the bottom. Columns are labeled by the start
+// time of their bucket formatted using layout, or when layout is empty, using
+// a compact layout suited to the width of the buckets.
+func (h *Heatmap) Grid(layout string) *grid {
+	if len(h.buckets) == 0 {
+		return newGrid(nil, nil)
+	}
+
+	rowLabels, maxBin, hasZeros := h.rows()
+
+	if layout == "" {
+		switch {
+		case h.bucket >= 24*time.Hour:
+			layout = "2006-01-02"
+		case h.max-h.min >= int64(24*time.Hour/h.bucket):
+			layout = "01-02 15:04"
+		default:
+			layout = "15:04"
+		}
+	}
+	colLabels := make([]string, h.max-h.min+1)
+	for i := range colLabels {
+		colLabels[i] = h.start(h.min + int64(i)).Format(layout)
+	}
+
+	g := newGrid(rowLabels, colLabels)
+	g.cellWidth = 1
+	g.gap = 0
+
+	for b, lb := range h.buckets {
+		col := int(b - h.min)
+		if hasZeros {
+			g.cells[len(rowLabels)-1][col] = float64(lb.zeros)
+		}
+		for i, count := range lb.counts {
+			g.cells[maxBin-i][col] = float64(count)
+		}
+	}
+
+	return g
+}
+
+// rows returns the label of each row of the grid of h, from the largest bin of
+// values at the top through the smallest at the bottom, along with the
+// largest bin, and whether the final row is for values of zero or less.
+func (h *Heatmap) rows() ([]string, int, bool) {
+	// Find the range of bins across all buckets.
+	var minBin, maxBin int
+	var hasBins, hasZeros bool
+	for _, lb := range h.buckets {
+		if lb.zeros > 0 {
+			hasZeros = true
+		}
+		if len(lb.counts) == 0 {
+			continue
+		}
+		if !hasBins || lb.min < minBin {
+			minBin = lb.min
+		}
+		if !hasBins || lb.max > maxBin {
+			maxBin = lb.max
+		}
+		hasBins = true
+	}
+
+	var rowLabels []string
+	if hasBins {
+		for i := maxBin; i >= minBin; i-- {
+			rowLabels = append(rowLabels, formatNumber(logBinLower(i)))
+		}
+	}
+	if hasZeros {
+		rowLabels = append(rowLabels, "0")
+	}
+	return rowLabels, maxBin, hasZeros
+}
+
+// LabelWidth returns the width of the widest row label of the grid of h,
+// which does not change when h is rebucketed.
+func (h *Heatmap) LabelWidth() int {
+	rowLabels, _, _ := h.rows()
+	var width int
+	for _, l := range rowLabels {
+		if width < len(l) {
+			width = len(l)
+		}
+	}
+	return width
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestHeatmapBuckets(t *testing.T) {
+	h := NewHeatmap(time.Minute, time.UTC)
+	h.Add(time.Date(2019, 7, 4, 12, 0, 10, 0, time.UTC), 3)
+	h.Add(time.Date(2019, 7, 4, 12, 0, 50, 0, time.UTC), 30)
+	h.Add(time.Date(2019, 7, 4, 12, 2, 0, 0, time.UTC), 0)
+
+	if got, want := len(h.buckets), 2; got != want {
+		t.Fatalf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := h.max-h.min, int64(2); got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := h.buckets[h.min].Total(), 2; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestHeatmapLocation(t *testing.T) {
+	h := NewHeatmap(24*time.Hour, time.FixedZone("east", 3*60*60))
+	h.Add(time.Date(2019, 7, 4, 22, 0, 0, 0, time.UTC), 1)
+
+	if got, want := h.start(h.min).Format("2006-01-02 15:04"), "2019-07-05 00:00"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestHeatmapFit(t *testing.T) {
+	h := NewHeatmap(time.Minute, time.UTC)
+	h.Add(time.Date(2019, 7, 4, 12, 0, 0, 0, time.UTC), 1)
+	h.Add(time.Date(2019, 7, 4, 13, 59, 0, 0, time.UTC), 1)
+
+	other := h.Fit(20)
+
+	if got, want := other.bucket, 10*time.Minute; got != want {
+		t.Fatalf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := other.max-other.min, int64(11); got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := other.buckets[other.min].Total()+other.buckets[other.max].Total(), 2; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestHeatmapLabelWidth(t *testing.T) {
+	h := NewHeatmap(time.Minute, time.UTC)
+	h.Add(time.Date(2019, 7, 4, 12, 0, 0, 0, time.UTC), 0.004)
+	h.Add(time.Date(2019, 7, 4, 12, 0, 0, 0, time.UTC), 1)
+
+	// The row for 0.002 is the widest.
+	if got, want := h.LabelWidth(), 5; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestHeatmapGrid(t *testing.T) {
+	h := NewHeatmap(time.Hour, time.UTC)
+	h.Add(time.Date(2019, 7, 4, 12, 0, 0, 0, time.UTC), 0)
+	h.Add(time.Date(2019, 7, 4, 14, 0, 0, 0, time.UTC), 1)
+	h.Add(time.Date(2019, 7, 4, 14, 0, 0, 0, time.UTC), 5)
+
+	g := h.Grid("")
+
+	if got, want := len(g.colLabels), 3; got != want {
+		t.Fatalf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := g.colLabels[2], "14:00"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	// Rows run from the largest bin at the top through the zero row.
+	wantRows := []string{"5", "2", "1", "0"}
+	if got, want := len(g.rowLabels), len(wantRows); got != want {
+		t.Fatalf("GOT: %v; WANT: %v", got, want)
+	}
+	for i, want := range wantRows {
+		if got := g.rowLabels[i]; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	}
+	if got, want := g.cells[0][2], 1.0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := g.cells[3][0], 1.0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := g.cells[1][2], 0.0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestHeatmapNonFinite(t *testing.T) {
+	when := time.Date(2019, 7, 4, 12, 0, 0, 0, time.UTC)
+	h := NewHeatmap(time.Minute, time.UTC)
+	h.Add(when, math.NaN())
+	h.Add(when.Add(time.Hour), math.Inf(1))
+	h.Add(when.Add(-time.Hour), math.Inf(-1))
+	h.Add(when, 1)
+
+	g := h.Grid("")
+
+	if got, want := len(g.colLabels), 1; got != want {
+		t.Fatalf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := len(g.rowLabels), 1; got != want {
+		t.Fatalf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := g.rowLabels[0], "1"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := g.cells[0][0], 1.0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/bits"
+)
+
+// hllPrecision is the number of bits of the hash of each value which select
+// its register. Using 2^12 registers of one byte each, estimates have a
+// standard error of about 1.6%.
+const hllPrecision = 12
+
+// HyperLogLog estimates the number of distinct values of a stream in fixed
+// memory, as described by Flajolet, Fusy, Gandouet, and Meunier, with the
+// small range correction of Heule, Nunkesser, and Hall. Sketches having the
+// same precision may be merged.
+type HyperLogLog struct {
+	precision uint
+	registers []uint8 // largest rank seen among the values of each register
+}
+
+// NewHyperLogLog returns an empty HyperLogLog using 2^precision registers.
+func NewHyperLogLog(precision uint) *HyperLogLog {
+	return &HyperLogLog{precision: precision, registers: make([]uint8, 1<<precision)}
+}
+
+// mixedHash returns the 64-bit FNV-1a hash of the value, with its bits mixed by
+// the finalizer of MurmurHash3 so that every bit depends on every byte of the
+// value. It is the same on every machine.
+func mixedHash(value string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(value))
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+// Add counts the value.
+func (h *HyperLogLog) Add(value string) {
+	x := mixedHash(value)
+	index := x >> (64 - h.precision)
+	// The rank is the position of the first set bit among the remaining
+	// bits, which is bounded by setting the bit following them.
+	rank := uint8(bits.LeadingZeros64(x<<h.precision|1<<(h.precision-1)) + 1)
+	if h.registers[index] < rank {
+		h.registers[index] = rank
+	}
+}
+
+// Count returns the estimated number of distinct values added.
+func (h *HyperLogLog) Count() int {
+	m := float64(len(h.registers))
+	var sum float64
+	var zeros int
+	for _, r := range h.registers {
+		sum += math.Ldexp(1, -int(r))
+		if r == 0 {
+			zeros++
+		}
+	}
+	estimate := 0.7213 / (1 + 1.079/m) * m * m / sum
+	if estimate <= 2.5*m && zeros > 0 {
+		// Linear counting is more accurate for small cardinalities.
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return int(estimate + 0.5)
+}
+
+// Merge adds the values counted by other, which must have the same precision.
+func (h *HyperLogLog) Merge(other *HyperLogLog) error {
+	if other.precision != h.precision {
+		return fmt.Errorf("cannot merge sketches of different precision: %d and %d", h.precision, other.precision)
+	}
+	for i, r := range other.registers {
+		if h.registers[i] < r {
+			h.registers[i] = r
+		}
+	}
+	return nil
+}
+
+// valueSet counts the distinct values of a stream exactly.
+type valueSet map[string]struct{}
+
+// Add counts the value.
+func (s valueSet) Add(value string) { s[value] = struct{}{} }
+
+// Count returns the number of distinct values added.
+func (s valueSet) Count() int { return len(s) }
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestHyperLogLogSmall(t *testing.T) {
+	h := NewHyperLogLog(hllPrecision)
+	for i := 0; i < 3; i++ {
+		h.Add("alice")
+		h.Add("bob")
+	}
+
+	if got, want := h.Count(), 2; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestHyperLogLogLarge(t *testing.T) {
+	h := NewHyperLogLog(hllPrecision)
+	for i := 0; i < 100000; i++ {
+		h.Add("user" + strconv.Itoa(i%50000))
+	}
+
+	// Within three standard errors.
+	if got := h.Count(); got < 47600 || got > 52400 {
+		t.Errorf("GOT: %v; WANT: %v", got, 50000)
+	}
+}
+
+func TestHyperLogLogMerge(t *testing.T) {
+	a := NewHyperLogLog(hllPrecision)
+	b := NewHyperLogLog(hllPrecision)
+	union := NewHyperLogLog(hllPrecision)
+	for i := 0; i < 20000; i++ {
+		value := strconv.Itoa(i)
+		if i < 15000 {
+			a.Add(value)
+		}
+		if i >= 5000 {
+			b.Add(value)
+		}
+		union.Add(value)
+	}
+
+	if err := a.Merge(b); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := a.Count(), union.Count(); got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if err := a.Merge(NewHyperLogLog(hllPrecision - 1)); err == nil {
+		t.Errorf("GOT: %v; WANT: %v", err, "error")
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import "time"
+
+// InterArrival bins the elapsed time between successive events, either across
+// all events when every event has the same key, or between successive events
+// sharing a key.
+type InterArrival struct {
+	bins       *LogBins
+	previous   map[string]time.Time // time of the most recent event of each key
+	OutOfOrder int                  // number of events earlier than the previous event of their key
+}
+
+// NewInterArrival returns an empty InterArrival.
+func NewInterArrival() *InterArrival {
+	return &InterArrival{bins: NewLogBins(), previous: make(map[string]time.Time)}
+}
+
+// Add records an event having the specified key at time t, counting the time
+// elapsed since the previous event having the same key.
+func (ia *InterArrival) Add(key string, t time.Time) {
+	previous, ok := ia.previous[key]
+	ia.previous[key] = t
+	if !ok {
+		return // first event of this key has no gap
+	}
+	gap := t.Sub(previous)
+	if gap < 0 {
+		ia.OutOfOrder++
+	}
+	ia.bins.Add(gap.Seconds())
+}
+
+// Table returns a table with a row for each log scale bin of elapsed time.
+func (ia *InterArrival) Table() *table {
+	return ia.bins.Table("Gap", formatSeconds)
+}
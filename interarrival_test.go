@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInterArrivalPerKey(t *testing.T) {
+	ia := NewInterArrival()
+
+	start := time.Date(2019, 7, 4, 12, 0, 0, 0, time.UTC)
+	ia.Add("a", start)
+	ia.Add("b", start.Add(time.Millisecond))
+	ia.Add("a", start.Add(time.Second))
+	ia.Add("b", start.Add(6*time.Second))
+
+	tab := ia.Table()
+
+	// 1s and 5s bins with empty 2s bin between them
+	if got, want := len(tab.rows), 3; got != want {
+		t.Fatalf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := tab.rows[0][0], "1s"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := tab.rows[1][1], "0"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := tab.rows[2][0], "5s"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestInterArrivalOutOfOrder(t *testing.T) {
+	ia := NewInterArrival()
+
+	start := time.Date(2019, 7, 4, 12, 0, 0, 0, time.UTC)
+	ia.Add("", start)
+	ia.Add("", start.Add(-time.Second))
+
+	if got, want := ia.OutOfOrder, 1; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := ia.bins.zeros, 1; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"math"
+	"strconv"
+	"time"
+)
+
+// logBinSeries is the mantissas of the lower bounds of each bin within a
+// decade.
+var logBinSeries = [...]float64{1, 2, 5}
+
+// LogBins counts non-negative values in logarithmically sized bins whose lower
+// bounds follow the 1-2-5 series across each decade, e.g., 1, 2, 5, 10, 20, 50,
+// 100, and so on. Because bins are created as needed, values of any magnitude
+// may be counted without configuring a range in advance.
+type LogBins struct {
+	counts   map[int]int // count of values by bin index
+	zeros    int         // count of values equal to zero, which has no logarithm
+	min, max int         // smallest and largest bin index having a value
+}
+
+// NewLogBins returns an empty LogBins.
+func NewLogBins() *LogBins {
+	return &LogBins{counts: make(map[int]int)}
+}
+
+// Add counts the value in its bin. Negative values are counted as zero.
+func (lb *LogBins) Add(value float64) {
+	lb.AddCount(value, 1)
+}
+
+// AddCount counts the value in its bin count times. Negative values are
+// counted as zero. NaN and infinite values, which belong in no bin, are
+// ignored.
+func (lb *LogBins) AddCount(value float64, count int) {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return
+	}
+	if value <= 0 {
+		lb.zeros += count
+		return
+	}
+	lb.addIndex(logBinIndex(value), count)
+}
+
+// addIndex counts count values in the bin having the specified index.
+func (lb *LogBins) addIndex(i, count int) {
+	if len(lb.counts) == 0 {
+		lb.min, lb.max = i, i
+	} else if i < lb.min {
+		lb.min = i
+	} else if i > lb.max {
+		lb.max = i
+	}
+	lb.counts[i] += count
+}
+
+// Merge adds the counts of other to the counts of lb.
+func (lb *LogBins) Merge(other *LogBins) {
+	lb.zeros += other.zeros
+	for i, count := range other.counts {
+		lb.addIndex(i, count)
+	}
+}
+
+// Total returns the number of values counted.
+func (lb *LogBins) Total() int {
+	total := lb.zeros
+	for _, c := range lb.counts {
+		total += c
+	}
+	return total
+}
+
+// logBinIndex returns the index of the bin the positive value belongs in.
+func logBinIndex(value float64) int {
+	decade := int(math.Floor(math.Log10(value)))
+	mantissa := value / math.Pow10(decade)
+	// Correct for floating point error near decade boundaries.
+	if mantissa < 1 {
+		decade--
+		mantissa *= 10
+	} else if mantissa >= 10 {
+		decade++
+		mantissa /= 10
+	}
+	i := len(logBinSeries) - 1
+	for mantissa < logBinSeries[i] {
+		i--
+	}
+	return decade*len(logBinSeries) + i
+}
+
+// logBinLower returns the lower bound of the bin having the specified index.
+func logBinLower(i int) float64 {
+	n := len(logBinSeries)
+	decade := i / n
+	if i%n < 0 {
+		decade-- // round towards negative infinity
+	}
+	return logBinSeries[i-decade*n] * math.Pow10(decade)
+}
+
+// Bins invokes callback with the lower bound and count of each bin in
+// ascending order, from the smallest bin through the largest bin having a
+// value, including empty bins between them. When any zero values were
+// counted, callback is first invoked with a lower bound of zero.
+func (lb *LogBins) Bins(callback func(lower float64, count int)) {
+	if lb.zeros > 0 {
+		callback(0, lb.zeros)
+	}
+	if len(lb.counts) == 0 {
+		return
+	}
+	for i := lb.min; i <= lb.max; i++ {
+		callback(logBinLower(i), lb.counts[i])
+	}
+}
+
+// Table returns a table with a row for each bin, labeled by the lower bound
+// of the bin formatted by label.
+func (lb *LogBins) Table(title string, label func(float64) string) *table {
+	t := newTable(title, "Count")
+	lb.Bins(func(lower float64, count int) {
+		t.Append(float64(count), label(lower), strconv.Itoa(count))
+	})
+	return t
+}
+
+// formatSeconds returns the number of seconds formatted as a duration.
+func formatSeconds(seconds float64) string {
+	return time.Duration(math.Round(seconds * float64(time.Second))).String()
+}
+
+// formatNumber returns the number formatted without superfluous digits.
+func formatNumber(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLogBinIndex(t *testing.T) {
+	cases := []struct {
+		value float64
+		index int
+	}{
+		{1, 0},
+		{1.99, 0},
+		{2, 1},
+		{4.99, 1},
+		{5, 2},
+		{9.99, 2},
+		{10, 3},
+		{1000, 9},
+		{0.001, -9},
+		{0.0015, -9},
+		{0.002, -8},
+		{0.5, -1},
+	}
+	for _, c := range cases {
+		if got, want := logBinIndex(c.value), c.index; got != want {
+			t.Errorf("%v: GOT: %v; WANT: %v", c.value, got, want)
+		}
+	}
+}
+
+func TestLogBinLower(t *testing.T) {
+	cases := []struct {
+		index int
+		lower float64
+	}{
+		{0, 1},
+		{1, 2},
+		{2, 5},
+		{3, 10},
+		{-1, 0.5},
+		{-3, 0.1},
+		{-9, 0.001},
+	}
+	for _, c := range cases {
+		if got, want := logBinLower(c.index), c.lower; got != want {
+			t.Errorf("%v: GOT: %v; WANT: %v", c.index, got, want)
+		}
+	}
+}
+
+func TestLogBinsIncludesEmptyBins(t *testing.T) {
+	lb := NewLogBins()
+	lb.Add(0)
+	lb.Add(1.5)
+	lb.Add(12)
+	lb.Add(13)
+
+	var lowers []float64
+	var counts []int
+	lb.Bins(func(lower float64, count int) {
+		lowers = append(lowers, lower)
+		counts = append(counts, count)
+	})
+
+	wantLowers := []float64{0, 1, 2, 5, 10}
+	wantCounts := []int{1, 1, 0, 0, 2}
+
+	if got, want := len(lowers), len(wantLowers); got != want {
+		t.Fatalf("GOT: %v; WANT: %v", got, want)
+	}
+	for i := range wantLowers {
+		if got, want := lowers[i], wantLowers[i]; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := counts[i], wantCounts[i]; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	}
+	if got, want := lb.Total(), 4; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestFormatSeconds(t *testing.T) {
+	if got, want := formatSeconds(logBinLower(-9)), "1ms"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := formatSeconds(logBinLower(1)), "2s"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestLogBinsMerge(t *testing.T) {
+	a := NewLogBins()
+	a.Add(0)
+	a.Add(3)
+
+	b := NewLogBins()
+	b.Add(30)
+	b.AddCount(3, 2)
+
+	a.Merge(b)
+
+	if got, want := a.Total(), 5; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := a.counts[logBinIndex(3)], 3; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := a.max, logBinIndex(30); got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestLogBinsNonFinite(t *testing.T) {
+	lb := NewLogBins()
+	lb.Add(math.NaN())
+	lb.Add(math.Inf(1))
+	lb.Add(math.Inf(-1))
+	lb.Add(3)
+
+	if got, want := lb.Total(), 1; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := lb.min, logBinIndex(3); got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := lb.max, logBinIndex(3); got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
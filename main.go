@@ -1,16 +1,17 @@
 package main // import "github.com/karrick/histogram"
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
-	"strings"
+	"strconv"
+	"time"
 
 	"github.com/karrick/gobls"
 	"github.com/karrick/gohistogram"
 	"github.com/karrick/golf"
-	"github.com/karrick/gorill"
 	"github.com/karrick/gows"
 )
 
@@ -48,6 +49,20 @@ func newline(s string) string {
 	return s[:1] // all newline characters, so just return the first one
 }
 
+// colorful returns true when standard output is a terminal expected to display
+// ANSI colors. Following convention, setting the NO_COLOR environment variable
+// disables colors.
+func colorful() bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	if term := os.Getenv("TERM"); term == "" || term == "dumb" {
+		return false
+	}
+	fi, err := os.Stdout.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
 // stderr formats and prints its arguments to standard error after prefixing
 // them with the program name.
 func stderr(f string, args ...interface{}) {
@@ -100,14 +115,74 @@ var (
 	optQuiet   = golf.BoolP('q', "quiet", false, "Do not print intermediate errors to stderr")
 	optVerbose = golf.BoolP('v', "verbose", false, "Print verbose output to stderr")
 
+	optApprox    = golf.Bool("approximate", false, "with --top, estimate the counts of the most frequent keys in fixed\n\tmemory, showing the amount each count may be overestimated")
+	optAverage   = golf.Bool("average", false, "with --cycle, show the mean count per day covered by the input")
+	optBucket    = golf.Duration("bucket", 0, "with --heatmap, width of each bucket of time. 0 implies the smallest\n\twidth that fits the output width, or 80 columns with --raw")
+	optCalendar  = golf.Bool("calendar", false, "display the count of each day as a calendar of shaded cells")
+	optCountMin  = golf.Bool("count-min", false, "estimate the counts of keys in fixed memory using a Count-Min sketch,\n\twhich may be saved, merged, and queried for the keys listed in --query")
+	optCountCol  = golf.String("count-column", "first", "with --precounted, whether the count is the first or the last field")
+	optCycle     = golf.String("cycle", "", "fold timestamps into the slots of a recurring period: hour, weekday, or\n\tweek (a grid of each hour of each weekday)")
+	optDelta     = golf.Float("delta", 0.01, "with --count-min, probability that an estimate exceeds --epsilon")
+	optDistinct  = golf.String("distinct", "", "field specification of a value whose number of distinct values for\n\teach --field key drives the bars, such as unique users per endpoint")
+	optDiff      = golf.Bool("diff", false, "compare the count of each key in two inputs, showing the change from\n\tthe first to the second")
 	optDelimiter = golf.StringP('d', "delimiter", "", "specify alternative field delimiter (empty string implies split on\n\twhitespace)")
+	optEpsilon   = golf.Float("epsilon", 0.001, "with --count-min, largest overestimate as a fraction of all lines")
+	optExact     = golf.Bool("exact", false, "with --distinct, count distinct values exactly rather than estimating\n\tthem with HyperLogLog in fixed memory per key")
 	optField     = golf.StringP('f', "field", "", "Comma delimited list of field specifications to use as the histogram key.\n\tField numbering starts at 1. May include open ranges, such as '-3,5' for the\n\tfirst three fields, followed by the fifth field. The empty string implies\n\tentire line.")
 	optFold      = golf.Bool("fold", false, "fold duplicate keys")
+	optHeatmap   = golf.Bool("heatmap", false, "display the distribution of --value-field over time as a grid of\n\tshaded cells, with a column for each bucket of time and a row for each\n\tlogarithmic bin of values")
+	optInterval  = golf.Bool("interarrival", false, "bin the time elapsed between successive timestamps, or between\n\tsuccessive timestamps of the same key when --field is given")
+	optMaxMemory = golf.String("max-memory", "", "count keys exactly using about this much memory for keys, such as 512M,\n\tspilling counts to temporary files beyond it")
 	optPercent   = golf.BoolP('p', "percentage", false, "show percentage")
+	optPrecount  = golf.Bool("precounted", false, "each input line is a count and a key, such as the output of 'uniq -c'\n\tor --raw, rather than a single occurrence of a key")
+	optQuery     = golf.String("query", "", "with --count-min, file listing a key on each line whose estimated count\n\tis shown")
+	optRate      = golf.Bool("rate", false, "show the span of time between the first and last timestamp of each key,\n\tand the rate at which it occurs over that span")
+	optRateBar   = golf.Bool("rate-bar", false, "with --rate, scale the histogram by rate rather than count")
+	optJobs      = golf.Int("jobs", 1, "number of workers counting inputs in parallel, splitting large files\n\tat line boundaries")
+	optLoad      = golf.String("load", "", "comma delimited list of files or glob patterns of states saved by\n\t--save, whose counts are merged with the counts of any input files")
 	optRaw       = golf.Bool("raw", false, "Print keys and counts")
+	optReservoir = golf.Int("reservoir", 0, "count a uniform random sample of this many lines")
+	optSample    = golf.String("sample", "", "count each line with this probability, such as 0.1, to quickly\n\testimate the histogram of a large input")
+	optSampleKey = golf.Bool("sample-by-key", false, "with --sample, count or drop every line of a key together by hashing\n\tthe key, so the counts of the keys shown are exact")
+	optSave      = golf.String("save", "", "write the count of each key to this file, to be merged later using\n\t--load")
+	optScale     = golf.Bool("scale", false, "with --sample or --reservoir, scale counts up to estimate the counts\n\tof all lines")
+	optShading   = golf.String("shading", "auto", "style of shaded cells: unicode, ascii, ansi, or auto, which uses ansi\n\tcolors when output is a terminal that supports them, and ascii otherwise")
 	optSortAsc   = golf.Bool("ascending", false, "print histogram in ascending order")
 	optSortDesc  = golf.Bool("descending", false, "print histogram in descending order")
+	optTop       = golf.Int("top", 0, "show only this many keys having the largest counts")
+	optMinCount  = golf.Int("min-count", 0, "show only keys counted at least this many times")
+	optMinPct    = golf.Float("min-percent", 0, "show only keys having at least this percentage of all lines")
+	optOther     = golf.Bool("other", false, "with --top, --min-count, or --min-percent, show a final (other) key\n\tsumming the counts of the keys not shown")
+	optUniqueBy  = golf.String("unique-by", "", "field specification of an identifier, counting each combination of\n\t--field key and identifier only once, such as each customer of an endpoint")
+	optValue     = golf.String("value-field", "", "field specification of the numeric value binned by --heatmap")
 	optWidth     = golf.IntP('w', "width", 0, "width of output histogram. 0 implies use tty width")
+
+	optTimeField  = golf.String("time-field", "", "Comma delimited list of field specifications of the timestamp, such as\n\t'1-2' when date and time are separate fields.")
+	optTimeFormat = golf.String("time-format", "rfc3339", "layout of the timestamp: ansic, clf, rfc1123, rfc1123z, rfc3339,\n\trfc3339nano, stamp, unixdate, unix, unixmilli, unixmicro, unixnano, or a\n\tGo time layout string")
+	optUTC        = golf.Bool("utc", false, "interpret and display timestamps in UTC rather than local time")
+
+	optOrdered = golf.Bool("ordered", false, "input timestamps are in ascending order, so stop reading each file once\n\ta timestamp reaches --until")
+	optSince   = golf.String("since", "", "ignore lines with timestamps before this time, which may be absolute,\n\tsuch as '2019-07-04 12:00', or relative to now, such as '-2h'")
+	optUntil   = golf.String("until", "", "ignore lines with timestamps at or after this time, which may be\n\tabsolute or relative to now")
+
+	optPairEnd     = golf.String("pair-end", "", "lines containing this word end the event of their --pair-id")
+	optPairID      = golf.String("pair-id", "", "field specification of the identifier correlating start and end lines")
+	optPairMax     = golf.Int("pair-max", defaultPairMax, "abandon the oldest unmatched start lines beyond this many. 0 implies no\n\tlimit")
+	optPairStart   = golf.String("pair-start", "", "lines containing this word start the event of their --pair-id")
+	optPairTimeout = golf.Duration("pair-timeout", 0, "abandon start lines unmatched after this duration. 0 implies no limit")
+
+	optCols       = golf.String("cols", "", "field specification of the column key of a cross tabulation")
+	optColPercent = golf.Bool("col-percent", false, "with --rows, show each cell as a percentage of its column total")
+	optRowPercent = golf.Bool("row-percent", false, "with --rows, show each cell as a percentage of its row total")
+	optRows       = golf.String("rows", "", "field specification of the row key of a cross tabulation of lines by\n\ttwo fields, such as status code by endpoint")
+	optShaded     = golf.Bool("shaded", false, "with --rows, display cells as shaded cells rather than numbers")
+	optStack      = golf.String("stack", "", "field specification of a secondary key splitting the bar of each --field\n\tkey into segments")
+
+	optGlobalScale = golf.Bool("global-scale", false, "with --group-by, scale the bars of every group alike so they may be\n\tcompared")
+	optPerFile     = golf.Bool("per-file", false, "show the count of each key in each input file, followed by their total")
+	optGroupBy     = golf.String("group-by", "", "field specification of the group of each line, displaying a histogram\n\tof the --field key for each group")
+	optGrouped     = golf.Bool("grouped", false, "with --per-file, show a bar for the count of each input rather than one\n\tfor their total")
+	optGroupTop    = golf.Int("group-top", 0, "with --group-by, show only this many keys having the largest counts in\n\teach group. 0 implies all keys")
 )
 
 func main() {
@@ -128,15 +203,131 @@ for reference.
 
     histogram [--quiet | [--force | --verbose]]
               [--delimiter STRING] [--field INTEGER] [--fold]
+              [--precounted [--count-column first|last]]
+              [--time-field SPEC [--time-format LAYOUT] [--utc]
+               [--since TIME] [--until TIME [--ordered]]]
+              [--ascending | --descending]
+              [--load FILE,...] [--save FILE] [--jobs INTEGER]
+              [--top INTEGER] [--min-count INTEGER] [--min-percent FLOAT]
+              [--other]
+              [--raw | [--percent | --width INTEGER]]
+              [file1 [file2 ...]]
+
+    histogram --max-memory SIZE [--delimiter STRING] [--field SPEC]
+              [--precounted [--count-column first|last]]
               [--ascending | --descending]
               [--raw | [--percent | --width INTEGER]]
               [file1 [file2 ...]]
 
+    histogram --unique-by SPEC [--delimiter STRING] [--field SPEC]
+              [--fold] [--ascending | --descending]
+              [--raw | [--percent | --width INTEGER]]
+              [file1 [file2 ...]]
+
+    histogram --sample FLOAT [--sample-by-key] | --reservoir INTEGER
+              [--scale] [--delimiter STRING] [--field SPEC]
+              [--fold] [--ascending | --descending]
+              [--raw | [--percent | --width INTEGER]]
+              [file1 [file2 ...]]
+
+    histogram --count-min [--epsilon FLOAT] [--delta FLOAT]
+              [--delimiter STRING] [--field INTEGER]
+              [--precounted [--count-column first|last]]
+              [--load FILE,...] [--save FILE] [--query FILE]
+              [--ascending | --descending]
+              [--raw | --width INTEGER]
+              [file1 [file2 ...]]
+
+    histogram --top INTEGER --approximate
+              [--delimiter STRING] [--field INTEGER]
+              [--precounted [--count-column first|last]]
+              [--ascending | --descending]
+              [--raw | --width INTEGER]
+              [file1 [file2 ...]]
+
+    histogram --time-field SPEC [--time-format LAYOUT] [--utc]
+              --cycle hour|weekday|week [--average]
+              [--raw | --width INTEGER] [--shading STYLE]
+              [file1 [file2 ...]]
+
+    histogram --time-field SPEC [--time-format LAYOUT] [--utc]
+              --calendar [--raw | --shading STYLE]
+              [file1 [file2 ...]]
+
+    histogram --time-field SPEC [--time-format LAYOUT] [--utc]
+              --heatmap --value-field SPEC [--bucket DURATION]
+              [--raw | [--width INTEGER] [--shading STYLE]]
+              [file1 [file2 ...]]
+
+    histogram --time-field SPEC [--time-format LAYOUT] [--utc]
+              --interarrival [--delimiter STRING] [--field SPEC]
+              [--raw | --width INTEGER]
+              [file1 [file2 ...]]
+
+    histogram --time-field SPEC [--time-format LAYOUT] [--utc]
+              --rate [--rate-bar] [--delimiter STRING] [--field SPEC]
+              [--ascending | --descending]
+              [--raw | --width INTEGER]
+              [file1 [file2 ...]]
+
+    histogram --time-field SPEC [--time-format LAYOUT] [--utc]
+              --pair-start STRING --pair-end STRING --pair-id SPEC
+              [--pair-timeout DURATION] [--pair-max INTEGER]
+              [--raw | --width INTEGER]
+              [file1 [file2 ...]]
+
+    histogram --group-by SPEC [--delimiter STRING] [--field SPEC]
+              [--group-top INTEGER] [--ascending | --descending]
+              [--raw | [--width INTEGER] [--global-scale]]
+              [file1 [file2 ...]]
+
+    histogram --stack SPEC [--delimiter STRING] [--field SPEC]
+              [--ascending | --descending]
+              [--raw | [--width INTEGER] [--shading STYLE]]
+              [file1 [file2 ...]]
+
+    histogram --per-file [--delimiter STRING] [--field SPEC]
+              [--ascending | --descending]
+              [--raw | [--width INTEGER] [--grouped [--shading STYLE]]]
+              file1 [file2 ...]
+
+    histogram --diff [--delimiter STRING] [--field SPEC] [--ascending]
+              [--raw | --width INTEGER]
+              before after
+
+    histogram --distinct SPEC [--exact] [--delimiter STRING] [--field SPEC]
+              [--ascending | --descending]
+              [--raw | --width INTEGER]
+              [file1 [file2 ...]]
+
+    histogram --rows SPEC --cols SPEC [--delimiter STRING]
+              [--row-percent | --col-percent]
+              [--raw | --shaded [--shading STYLE]]
+              [file1 [file2 ...]]
+
 EXAMPLES:
 
     histogram < sample.txt
     histogram sample.txt
     last | histogram --field 1 --fold --descending
+    histogram --time-field 4-5 --time-format clf --cycle hour access.log
+    histogram --time-field 1 --since -2h --field 3 --fold app.log
+    histogram --field 3 --save monday.hist monday.log
+    histogram --load 'week/*.hist' --descending
+    sort | uniq -c | histogram --precounted
+    histogram --field 7 --top 20 --approximate access.log
+    histogram --field 7 --fold --jobs 8 huge.log
+    histogram --field 7 --descending --top 10 --other --percent access.log
+    histogram --field 7 --max-memory 256M --descending huge.log
+    histogram --field 7 --unique-by 3 --fold --descending access.log
+    histogram --field 7 --sample 0.01 --scale --fold --descending huge.log
+    histogram --field 7 --count-min --load 'hosts/*.cms' --query urls.txt
+    histogram --rows 9 --cols 7 access.log
+    histogram --field 7 --stack 9 --descending access.log
+    histogram --per-file --field 7 --descending yesterday.log today.log
+    histogram --diff --field 7 baseline.log canary.log
+    histogram --distinct 3 --field 7 --descending access.log
+    histogram --group-by 2 --field 5- --group-top 3 --descending app.log
 
 Command line options:
 `)
@@ -147,6 +338,254 @@ Command line options:
 	if *optSortAsc && *optSortDesc {
 		usage("cannot use both --ascending and --descending")
 	}
+
+	// Each of these options selects a mode that displays something other
+	// than a histogram of keys, therefore at most one of them may be used.
+	var modes []string
+	if *optCycle != "" {
+		modes = append(modes, "--cycle")
+	}
+	if *optCalendar {
+		modes = append(modes, "--calendar")
+	}
+	if *optHeatmap {
+		if *optValue == "" {
+			usage("cannot use --heatmap without --value-field")
+		}
+		modes = append(modes, "--heatmap")
+	} else if *optBucket != 0 {
+		usage("cannot use --bucket without --heatmap")
+	}
+	if *optInterval {
+		modes = append(modes, "--interarrival")
+	}
+	if *optRate {
+		modes = append(modes, "--rate")
+	}
+	if *optPairStart != "" || *optPairEnd != "" || *optPairID != "" {
+		if *optPairStart == "" || *optPairEnd == "" || *optPairID == "" {
+			usage("cannot use any of --pair-start, --pair-end, or --pair-id without the others")
+		}
+		if *optPairMax < 0 {
+			usage("cannot use negative --pair-max: %d", *optPairMax)
+		}
+		modes = append(modes, "--pair-start")
+	}
+	if *optRows != "" || *optCols != "" {
+		if *optRows == "" || *optCols == "" {
+			usage("cannot use either --rows or --cols without the other")
+		}
+		modes = append(modes, "--rows")
+	}
+	if *optGroupBy != "" {
+		modes = append(modes, "--group-by")
+	}
+	if *optStack != "" {
+		modes = append(modes, "--stack")
+	}
+	if *optPerFile {
+		modes = append(modes, "--per-file")
+	}
+	if *optDiff {
+		if golf.NArg() != 2 {
+			usage("cannot use --diff without exactly two inputs")
+		}
+		modes = append(modes, "--diff")
+	}
+	if *optDistinct != "" {
+		modes = append(modes, "--distinct")
+	} else if *optExact {
+		usage("cannot use --exact without --distinct")
+	}
+	if len(modes) > 1 {
+		usage("cannot use both %s and %s", modes[0], modes[1])
+	}
+	if len(modes) == 1 {
+		// Some modes count fields rather than timestamps, so only need
+		// --time-field to filter by time.
+		counted := *optRows != "" || *optGroupBy != "" || *optStack != "" || *optPerFile || *optDiff || *optDistinct != ""
+		if *optTimeField == "" && !counted {
+			usage("cannot use %s without --time-field", modes[0])
+		}
+		// Some modes are always folded by key, and may be sorted, but the
+		// other modes display their rows in a natural order.
+		sortable := *optRate || *optGroupBy != "" || *optStack != "" || *optPerFile || *optDiff || *optDistinct != ""
+		if !sortable {
+			if *optSortAsc || *optSortDesc {
+				usage("cannot use %s with --ascending or --descending", modes[0])
+			}
+			if *optFold {
+				usage("cannot use %s with --fold", modes[0])
+			}
+		}
+		if *optPercent {
+			usage("cannot use %s with --percent", modes[0])
+		}
+		if *optSave != "" || *optLoad != "" || *optPrecount || *optCountMin {
+			usage("cannot use %s with --save, --load, --precounted, or --count-min", modes[0])
+		}
+		if *optTop != 0 || *optMinCount != 0 || *optMinPct != 0 {
+			usage("cannot use %s with --top, --min-count, or --min-percent", modes[0])
+		}
+	}
+	if *optRateBar && !*optRate {
+		usage("cannot use --rate-bar without --rate")
+	}
+	if (*optRowPercent || *optColPercent || *optShaded) && *optRows == "" {
+		usage("cannot use --row-percent, --col-percent, or --shaded without --rows")
+	}
+	if *optRowPercent && *optColPercent {
+		usage("cannot use both --row-percent and --col-percent")
+	}
+	if (*optGlobalScale || *optGroupTop != 0) && *optGroupBy == "" {
+		usage("cannot use --global-scale or --group-top without --group-by")
+	}
+	if *optGrouped {
+		if !*optPerFile {
+			usage("cannot use --grouped without --per-file")
+		}
+		if *optRaw {
+			usage("cannot use both --raw and --grouped")
+		}
+	}
+	if *optGroupTop < 0 {
+		usage("cannot use negative --group-top: %d", *optGroupTop)
+	}
+	if *optCountCol != "first" {
+		if *optCountCol != "last" {
+			usage("cannot use --count-column other than first or last: %q", *optCountCol)
+		}
+		if !*optPrecount {
+			usage("cannot use --count-column without --precounted")
+		}
+	}
+	if *optTop < 0 {
+		usage("cannot use negative --top: %d", *optTop)
+	}
+	if *optMinCount < 0 {
+		usage("cannot use negative --min-count: %d", *optMinCount)
+	}
+	if *optMinPct < 0 || *optMinPct > 100 {
+		usage("cannot use --min-percent outside of the range [0, 100]: %g", *optMinPct)
+	}
+	limited := *optTop != 0 || *optMinCount != 0 || *optMinPct != 0
+	if *optOther && !limited {
+		usage("cannot use --other without --top, --min-count, or --min-percent")
+	}
+	if *optCountMin {
+		if *optQuery == "" && *optSave == "" {
+			usage("cannot use --count-min without --query or --save")
+		}
+		if *optApprox {
+			usage("cannot use both --count-min and --approximate")
+		}
+		if *optPercent {
+			usage("cannot use --count-min with --percent")
+		}
+	} else if *optQuery != "" {
+		usage("cannot use --query without --count-min")
+	}
+	if *optApprox {
+		if *optTop == 0 {
+			usage("cannot use --approximate without --top")
+		}
+		if *optSave != "" || *optLoad != "" {
+			usage("cannot use --approximate with --save or --load")
+		}
+		if *optPercent {
+			usage("cannot use --approximate with --percent")
+		}
+		if *optMinCount != 0 || *optMinPct != 0 || *optOther {
+			usage("cannot use --approximate with --min-count, --min-percent, or --other")
+		}
+	}
+	if limited && (*optCountMin || *optMaxMemory != "") {
+		usage("cannot use --top, --min-count, or --min-percent with --count-min or --max-memory")
+	}
+	if *optUniqueBy != "" {
+		if len(modes) > 0 {
+			usage("cannot use both %s and --unique-by", modes[0])
+		}
+		if *optSave != "" || *optLoad != "" || *optPrecount {
+			usage("cannot use --unique-by with --save, --load, or --precounted")
+		}
+		if *optApprox || *optCountMin {
+			usage("cannot use --unique-by with --approximate or --count-min")
+		}
+	}
+	if *optMaxMemory != "" {
+		if _, err := parseSize(*optMaxMemory); err != nil {
+			usage("cannot use --max-memory: %s", err)
+		}
+		if len(modes) > 0 {
+			usage("cannot use both %s and --max-memory", modes[0])
+		}
+		if *optSave != "" || *optLoad != "" || *optUniqueBy != "" {
+			usage("cannot use --max-memory with --save, --load, or --unique-by")
+		}
+		if *optApprox || *optCountMin {
+			usage("cannot use --max-memory with --approximate or --count-min")
+		}
+	}
+	if *optSample != "" || *optReservoir != 0 {
+		if *optSample != "" && *optReservoir != 0 {
+			usage("cannot use both --sample and --reservoir")
+		}
+		if *optSample != "" {
+			rate, err := strconv.ParseFloat(*optSample, 64)
+			if err != nil {
+				usage("cannot parse --sample: %s", err)
+			}
+			if rate <= 0 || rate > 1 {
+				usage("cannot use --sample outside of the range (0, 1]: %g", rate)
+			}
+		}
+		if *optReservoir < 0 {
+			usage("cannot use negative --reservoir: %d", *optReservoir)
+		}
+		if len(modes) > 0 {
+			usage("cannot use %s with --sample or --reservoir", modes[0])
+		}
+		if *optSave != "" || *optLoad != "" || *optPrecount {
+			usage("cannot use --sample or --reservoir with --save, --load, or --precounted")
+		}
+		if *optApprox || *optCountMin || *optMaxMemory != "" || *optUniqueBy != "" {
+			usage("cannot use --sample or --reservoir with --approximate, --count-min, --max-memory, or --unique-by")
+		}
+	} else if *optScale {
+		usage("cannot use --scale without --sample or --reservoir")
+	}
+	if *optSampleKey {
+		if *optSample == "" {
+			usage("cannot use --sample-by-key without --sample")
+		}
+		if *optScale {
+			usage("cannot use --scale with --sample-by-key, whose counts are already exact")
+		}
+	}
+	if *optJobs < 1 {
+		usage("cannot use --jobs fewer than 1: %d", *optJobs)
+	}
+	if *optJobs > 1 {
+		if len(modes) > 0 {
+			usage("cannot use %s with --jobs", modes[0])
+		}
+		if *optApprox || *optCountMin || *optMaxMemory != "" {
+			usage("cannot use --jobs with --approximate, --count-min, or --max-memory")
+		}
+		if *optUniqueBy != "" || *optSample != "" || *optReservoir != 0 {
+			usage("cannot use --jobs with --unique-by, --sample, or --reservoir")
+		}
+	}
+	if *optAverage && *optCycle == "" {
+		usage("cannot use --average without --cycle")
+	}
+	if (*optSince != "" || *optUntil != "") && *optTimeField == "" {
+		usage("cannot use --since or --until without --time-field")
+	}
+	if *optOrdered && *optUntil == "" {
+		usage("cannot use --ordered without --until")
+	}
 	if *optRaw {
 		if *optPercent {
 			usage("cannot use both --raw and --percent")
@@ -154,6 +593,9 @@ Command line options:
 		if *optWidth > 0 {
 			usage("cannot use both --raw and --width N")
 		}
+		if *optShaded {
+			usage("cannot use both --raw and --shaded")
+		}
 	} else if *optWidth == 0 {
 		var err error
 		*optWidth, _, err = gows.GetWinSize()
@@ -163,34 +605,274 @@ Command line options:
 		}
 	}
 
+	location := time.Local
+	if *optUTC {
+		location = time.UTC
+	}
+
+	var tp *TimeParser
+	var window TimeWindow
+	if *optTimeField != "" {
+		var err error
+		if tp, err = NewTimeParser(*optTimeField, *optDelimiter, *optTimeFormat, location); err != nil {
+			fatal(err)
+		}
+		now := time.Now()
+		if *optSince != "" {
+			if window.Since, err = ParseTimeBound(*optSince, now, *optTimeFormat, location); err != nil {
+				usage("cannot use --since: %s", err)
+			}
+		}
+		if *optUntil != "" {
+			if window.Until, err = ParseTimeBound(*optUntil, now, *optTimeFormat, location); err != nil {
+				usage("cannot use --until: %s", err)
+			}
+		}
+	}
+
+	shades, err := parseShading(*optShading, colorful())
+	if err != nil {
+		usage("%s", err)
+	}
+
+	if *optCycle != "" {
+		if err = cycle(tp, window, location, shades); err != nil {
+			fatal(err)
+		}
+		return
+	}
+
+	if *optCalendar {
+		if err = calendar(tp, window, location, shades); err != nil {
+			fatal(err)
+		}
+		return
+	}
+
+	if *optHeatmap {
+		if err = heatmap(tp, window, location, shades); err != nil {
+			fatal(err)
+		}
+		return
+	}
+
+	if *optRows != "" {
+		if err = crosstab(tp, window, shades); err != nil {
+			fatal(err)
+		}
+		return
+	}
+
 	fs, err := NewFieldSplitter(*optField, *optDelimiter)
 	if err != nil {
 		fatal(err)
 	}
 
-	var ior io.Reader
-	if golf.NArg() == 0 {
-		ior = os.Stdin
-	} else {
-		ior = &gorill.FilesReader{Pathnames: golf.Args()}
+	if *optInterval {
+		if err = interarrival(tp, window, fs); err != nil {
+			fatal(err)
+		}
+		return
 	}
 
-	sh := new(gohistogram.Strings)
+	if *optPairStart != "" {
+		if err = pair(tp, window); err != nil {
+			fatal(err)
+		}
+		return
+	}
 
-	if err = ingest(ior, sh, fs); err != nil {
-		fatal(err)
+	if *optRate {
+		if err = rate(tp, window, fs); err != nil {
+			fatal(err)
+		}
+		return
+	}
+
+	if *optGroupBy != "" {
+		if err = groupBy(tp, window, fs); err != nil {
+			fatal(err)
+		}
+		return
+	}
+
+	if *optStack != "" {
+		if err = stack(tp, window, fs, shades); err != nil {
+			fatal(err)
+		}
+		return
+	}
+
+	if *optDiff {
+		if err = diff(tp, window, fs); err != nil {
+			fatal(err)
+		}
+		return
+	}
+
+	if *optPerFile {
+		if err = perFile(tp, window, fs, shades); err != nil {
+			fatal(err)
+		}
+		return
+	}
+
+	if *optDistinct != "" {
+		if err = distinct(tp, window, fs); err != nil {
+			fatal(err)
+		}
+		return
+	}
+
+	if *optCountMin {
+		if err = countMin(tp, window, fs); err != nil {
+			fatal(err)
+		}
+		return
+	}
+
+	if *optApprox {
+		if err = approximate(tp, window, fs); err != nil {
+			fatal(err)
+		}
+		return
+	}
+
+	if *optMaxMemory != "" {
+		if err = spilled(tp, window, fs); err != nil {
+			fatal(err)
+		}
+		return
+	}
+
+	var sh histogram = new(gohistogram.Strings)
+
+	// A Counter folds keys as they are added, so it requires memory for each
+	// distinct key rather than for each run of adjacent identical keys.
+	// Saving and loading state, scaling, and limiting the keys shown also
+	// require reading back the count of each key, and pre-counted input
+	// requires adding many of a key at once, which only a Counter allows.
+	var counter *Counter
+	if *optFold || *optSave != "" || *optLoad != "" || *optPrecount || *optScale || limited {
+		counter = NewCounter()
+		sh = counter
+	}
+
+	if *optLoad != "" {
+		pathnames, err := statePathnames(*optLoad)
+		if err != nil {
+			fatal(err)
+		}
+		for _, pathname := range pathnames {
+			options, err := LoadState(pathname, counter)
+			if err != nil {
+				fatal(err)
+			}
+			checkStateOptions(pathname, options)
+		}
+	}
+
+	// When sampling, the histogram is marked with a description of the
+	// sample.
+	var sample string
+
+	// When loading state, only read input when it is named, so the saved
+	// states may be displayed without waiting on standard input.
+	if *optLoad == "" || golf.NArg() > 0 {
+		add := func(key string, count int) {
+			for i := 0; i < count; i++ {
+				sh.Add(key)
+			}
+		}
+		if counter != nil {
+			add = counter.AddCount
+		}
+		callback := counted(fs, add)
+		var pairs *PairSet
+		if *optUniqueBy != "" {
+			ids, err := NewFieldSplitter(*optUniqueBy, *optDelimiter)
+			if err != nil {
+				fatal(err)
+			}
+			pairs = NewPairSet()
+			callback = deduplicated(fs, ids, pairs, sh.Add)
+		}
+		var sampler *Sampler
+		var reservoir *Reservoir
+		if *optSample != "" {
+			rate, _ := strconv.ParseFloat(*optSample, 64) // validated above
+			sampler = NewSampler(rate, *optSampleKey, time.Now().UnixNano())
+			callback = sampled(fs, sampler, callback)
+		} else if *optReservoir != 0 {
+			reservoir = NewReservoir(*optReservoir, time.Now().UnixNano())
+			callback = reservoir.Add
+		}
+		// When each line is simply counted by its key, keys may be selected
+		// from the bytes of each line, only allocating a string for each
+		// key not already counted.
+		plain := window.IsZero() && !*optPrecount && *optUniqueBy == "" && sampler == nil && reservoir == nil
+		if *optJobs > 1 {
+			chunks, err := planChunks(golf.Args(), *optJobs, minChunkSize)
+			if err != nil {
+				fatal(err)
+			}
+			err = ingestParallel(chunks, *optJobs, counter != nil, func(kc keyCounter) func([]byte) bool {
+				if plain {
+					return countedBytes(fs, kc)
+				}
+				return text(filtered(tp, window, counted(fs, kc.AddCount)))
+			}, add)
+			if err != nil {
+				fatal(err)
+			}
+		} else if plain && counter != nil {
+			if err = ingestInputBytes(countedBytes(fs, counter)); err != nil {
+				fatal(err)
+			}
+		} else if err = ingestInputs(filtered(tp, window, callback)); err != nil {
+			fatal(err)
+		}
+		if sampler != nil {
+			sample = describeSample(sampler.Kept(), sampler.Seen(), counter)
+		} else if reservoir != nil {
+			reservoir.Each(counted(fs, add))
+			sample = describeSample(reservoir.Kept(), reservoir.Seen(), counter)
+		}
+		if pairs != nil {
+			warning("dropped %d duplicate pairs of key and identifier, counting %d unique pairs", pairs.Duplicates(), pairs.Len())
+		}
 	}
 
 	if *optFold {
 		sh.FoldDuplicateKeys()
 	}
 
+	if *optSave != "" {
+		if err = SaveState(*optSave, counter, stateOptions()); err != nil {
+			fatal(err)
+		}
+	}
+
 	if *optSortDesc {
 		sh.SortDescending()
 	} else if *optSortAsc {
 		sh.SortAscending()
 	}
 
+	if limited {
+		var other string
+		if *optOther {
+			other = "(other)"
+		}
+		if removed := counter.Limit(*optTop, *optMinCount, *optMinPct, other); removed > 0 {
+			verbose("omitted %d keys", removed)
+		}
+	}
+
+	if sample != "" {
+		markSampled(sample)
+	}
+
 	if *optRaw {
 		err = sh.PrintRaw()
 	} else if *optPercent {
@@ -203,16 +885,243 @@ Command line options:
 	}
 }
 
-func ingest(ior io.Reader, hist *gohistogram.Strings, fs *FieldSplitter) error {
+// ingestInputs invokes callback with each non-empty line of each file named on
+// the command line, or of standard input when no files are named. When
+// callback returns false, the remainder of the current file is skipped.
+func ingestInputs(callback func(string) bool) error {
+	return ingestInputBytes(text(callback))
+}
+
+// ingestInputBytes invokes callback with the bytes of each non-empty line of
+// the files named on the command line, or of standard input when none are
+// named.
+func ingestInputBytes(callback func([]byte) bool) error {
+	if golf.NArg() == 0 {
+		return ingestBytes(os.Stdin, callback)
+	}
+	for _, pathname := range golf.Args() {
+		if err := ingestFileBytes(pathname, callback); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ingestFile invokes callback with each non-empty line of the file, or of
+// standard input when pathname is "-".
+func ingestFile(pathname string, callback func(string) bool) error {
+	return ingestFileBytes(pathname, text(callback))
+}
+
+// ingestFileBytes invokes callback with the bytes of each non-empty line of
+// the file, or of standard input when pathname is "-".
+func ingestFileBytes(pathname string, callback func([]byte) bool) error {
+	return ingestSection(pathname, 0, 0, callback)
+}
+
+// ingestSection invokes callback with the bytes of each non-empty line of the
+// section of the file from start up to end, or of the entire file when end is
+// zero. Standard input, named by "-", is always read in its entirety. Regular
+// files are mapped into memory, and other files are read by streaming.
+func ingestSection(pathname string, start, end int64, callback func([]byte) bool) error {
+	if pathname == "-" {
+		return ingestBytes(os.Stdin, callback)
+	}
+	fh, err := os.Open(pathname)
+	if err != nil {
+		return err
+	}
+	var mapped bool
+	if mapped, err = ingestMapped(fh, start, end, callback); !mapped {
+		if end == 0 {
+			err = ingestBytes(fh, callback)
+		} else {
+			err = ingestBytes(io.NewSectionReader(fh, start, end-start), callback)
+		}
+	}
+	if cerr := fh.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// ingestBytes invokes callback with the bytes of each non-empty line read from
+// ior, after removing its line ending. The bytes are only valid until callback
+// returns. When callback returns false, ingestBytes stops reading from ior and
+// returns.
+func ingestBytes(ior io.Reader, callback func([]byte) bool) error {
 	scanner := gobls.NewScanner(ior)
 	for scanner.Scan() {
-		// Remove line ending and split line into fields, then join into string
-		key := fs.Select(strings.TrimRight(scanner.Text(), "\r\n"))
+		line := bytes.TrimRight(scanner.Bytes(), "\r\n")
 
 		// ignore empty string at the end of the input
-		if len(key) > 0 {
-			hist.Add(key)
+		if len(line) > 0 && !callback(line) {
+			break
 		}
 	}
 	return scanner.Err()
 }
+
+// text returns a callback of the bytes of each line which invokes callback
+// with the line.
+func text(callback func(string) bool) func([]byte) bool {
+	return func(line []byte) bool {
+		return callback(string(line))
+	}
+}
+
+// timed returns an ingest callback that parses the timestamp of each line, then
+// invokes callback with each line whose timestamp falls within window. Lines
+// without a timestamp that can be parsed are skipped with a warning. When
+// --ordered is given, the remainder of an input is skipped once a timestamp
+// reaches the end of window.
+func timed(tp *TimeParser, window TimeWindow, callback func(string, time.Time)) func(string) bool {
+	return func(line string) bool {
+		t, err := tp.Parse(line)
+		if err != nil {
+			warning("%s", err)
+			return true
+		}
+		if !window.Contains(t) {
+			return !(*optOrdered && window.Ended(t))
+		}
+		callback(line, t)
+		return true
+	}
+}
+
+// countedBytes returns an ingest callback which selects the key of each line
+// and counts it once using kc. Keys are selected into a buffer reused for each
+// line, so lines are only allocated when kc stores a new key.
+func countedBytes(fs *FieldSplitter, kc keyCounter) func([]byte) bool {
+	var key []byte
+	return func(line []byte) bool {
+		if key = fs.AppendSelect(key[:0], line); len(key) > 0 {
+			kc.AddBytes(key, 1)
+		}
+		return true
+	}
+}
+
+// counted returns a function which selects the key of each line and invokes
+// add with it and its count, which is one unless --precounted is given, in
+// which case the count is parsed from the line.
+func counted(fs *FieldSplitter, add func(key string, count int)) func(string) {
+	if !*optPrecount {
+		return func(line string) {
+			// Split line into fields, then join into string
+			if key := fs.Select(line); len(key) > 0 {
+				add(key, 1)
+			}
+		}
+	}
+	last := *optCountCol == "last"
+	return func(line string) {
+		count, rest, err := splitCounted(line, last, *optDelimiter)
+		if err != nil {
+			warning("%s", err)
+			return
+		}
+		if key := fs.Select(rest); len(key) > 0 {
+			add(key, count)
+		}
+	}
+}
+
+// deduplicated returns a function which selects the key and identifier of
+// each line and invokes add with the key, unless that pair of key and
+// identifier was already seen. Lines lacking either are ignored.
+func deduplicated(fs, ids *FieldSplitter, pairs *PairSet, add func(key string)) func(string) {
+	return func(line string) {
+		key, id := fs.Select(line), ids.Select(line)
+		if len(key) > 0 && len(id) > 0 && pairs.Add(key, id) {
+			add(key)
+		}
+	}
+}
+
+// sampled returns a function which invokes callback with each line the
+// sampler keeps, selecting the key of each line when sampling by key.
+func sampled(fs *FieldSplitter, sampler *Sampler, callback func(string)) func(string) {
+	return func(line string) {
+		var key string
+		if *optSampleKey {
+			key = fs.Select(line)
+		}
+		if sampler.Keep(key) {
+			callback(line)
+		}
+	}
+}
+
+// describeSample returns a description of a sample of kept lines out of seen
+// lines. When --scale is given, it also scales the counts of counter to
+// estimate the counts of every line.
+func describeSample(kept, seen int, counter *Counter) string {
+	unit := "lines"
+	if *optSampleKey {
+		unit = "lines, by key"
+	}
+	description := fmt.Sprintf("sampled %d of %d %s", kept, seen, unit)
+	if *optScale && kept > 0 {
+		factor := float64(seen) / float64(kept)
+		counter.Scale(factor)
+		description += fmt.Sprintf(", counts scaled by %.3g", factor)
+	}
+	return description
+}
+
+// markSampled prints the description of a sample ahead of the histogram. Raw
+// output is kept machine readable by printing it to standard error instead,
+// even with --quiet, because it is the only sign the counts were sampled.
+func markSampled(sample string) {
+	if *optRaw {
+		stderr("%s", sample)
+		return
+	}
+	fmt.Printf("(%s)\n", sample)
+}
+
+// filtered returns an ingest callback that invokes callback with each line,
+// or when window bounds either side, with each line whose timestamp falls
+// within window.
+func filtered(tp *TimeParser, window TimeWindow, callback func(string)) func(string) bool {
+	if window.IsZero() {
+		return func(line string) bool {
+			callback(line)
+			return true
+		}
+	}
+	return timed(tp, window, func(line string, _ time.Time) {
+		callback(line)
+	})
+}
+
+// checkStateOptions warns when the options used to select the keys of a saved
+// state differ from the current options.
+func checkStateOptions(pathname string, options map[string]string) {
+	current := stateOptions()
+	for _, name := range []string{"field", "delimiter"} {
+		if options[name] != current[name] {
+			warning("%s was saved with --%s %q rather than %q", pathname, name, options[name], current[name])
+		}
+	}
+}
+
+// stateOptions returns the options which determine the keys counted, to be
+// recorded along with saved state.
+func stateOptions() map[string]string {
+	options := make(map[string]string)
+	for name, value := range map[string]string{
+		"delimiter":  *optDelimiter,
+		"field":      *optField,
+		"since":      *optSince,
+		"time-field": *optTimeField,
+		"until":      *optUntil,
+	} {
+		if value != "" {
+			options[name] = value
+		}
+	}
+	return options
+}
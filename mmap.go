@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"os"
+)
+
+// ingestMapped maps the file into memory, then invokes callback with the bytes
+// of each non-empty line of the section from start up to end, or of the entire
+// file when end is zero, exactly as ingestBytes would when reading them. Lines
+// are scanned directly from the mapped memory, avoiding the system calls and
+// copying of reading the file. It returns false without invoking callback when
+// the file cannot be mapped, such as when it is not a regular file, so it may
+// be read by streaming instead.
+func ingestMapped(fh *os.File, start, end int64, callback func([]byte) bool) (bool, error) {
+	fi, err := fh.Stat()
+	if err != nil || !fi.Mode().IsRegular() || fi.Size() == 0 {
+		return false, nil
+	}
+	size := int(fi.Size())
+	if int64(size) != fi.Size() {
+		return false, nil // too large to map on this platform
+	}
+	buf, unmap, err := mapFile(fh, size)
+	if err != nil {
+		return false, nil
+	}
+
+	if end == 0 || end > int64(size) {
+		end = int64(size)
+	}
+	if start < end {
+		scanLines(buf[start:end], callback)
+	}
+	return true, unmap()
+}
+
+// scanLines invokes callback with each non-empty line of buf, after removing
+// its line ending. When callback returns false, scanLines stops and returns.
+func scanLines(buf []byte, callback func([]byte) bool) {
+	for len(buf) > 0 {
+		var line []byte
+		if i := bytes.IndexByte(buf, '\n'); i >= 0 {
+			line, buf = buf[:i], buf[i+1:]
+		} else {
+			line, buf = buf, nil
+		}
+		line = bytes.TrimRight(line, "\r\n")
+
+		// ignore empty lines, as ingestBytes does
+		if len(line) > 0 && !callback(line) {
+			return
+		}
+	}
+}
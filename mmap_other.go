@@ -0,0 +1,15 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+// mapFile always fails on platforms where files are not mapped into memory,
+// so they are read by streaming instead.
+func mapFile(_ *os.File, _ int) ([]byte, func() error, error) {
+	return nil, nil, errors.New("cannot map files into memory on this platform")
+}
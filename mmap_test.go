@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestScanLinesMatchesIngest(t *testing.T) {
+	for _, input := range []string{
+		"",
+		"a\nb\n",
+		"a\r\nb\r\n",
+		"a\n\n\r\nb",
+		"c\r\r\n a \nlast",
+		"\n\n",
+	} {
+		var want []string
+		err := ingestBytes(bytes.NewReader([]byte(input)), func(line []byte) bool {
+			want = append(want, string(line))
+			return true
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got []string
+		scanLines([]byte(input), func(line []byte) bool {
+			got = append(got, string(line))
+			return true
+		})
+
+		if len(got) != len(want) {
+			t.Fatalf("%q: GOT: %q; WANT: %q", input, got, want)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("%q: GOT: %q; WANT: %q", input, got[i], want[i])
+			}
+		}
+	}
+}
+
+func TestIngestMapped(t *testing.T) {
+	dir, err := ioutil.TempDir("", "histogram")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	pathname := filepath.Join(dir, "input.log")
+	if err = ioutil.WriteFile(pathname, []byte("one\ntwo\nthree\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fh, err := os.Open(pathname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fh.Close()
+
+	var got []string
+	mapped, err := ingestMapped(fh, 4, 8, func(line []byte) bool {
+		got = append(got, string(line))
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !mapped {
+		t.Skip("cannot map files into memory on this platform")
+	}
+
+	if got, want := len(got), 1; got != want {
+		t.Fatalf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := got[0], "two"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestIngestMappedPipe(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	mapped, err := ingestMapped(r, 0, 0, func([]byte) bool { return true })
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mapped {
+		t.Errorf("GOT: %v; WANT: %v", mapped, false)
+	}
+}
@@ -0,0 +1,593 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/karrick/golf"
+)
+
+// cycle folds the timestamp of each input line into the slots of the
+// recurring period specified by --cycle, then prints them.
+func cycle(tp *TimeParser, window TimeWindow, location *time.Location, shades shading) error {
+	c, err := NewCyclical(*optCycle, location)
+	if err != nil {
+		return err
+	}
+
+	err = ingestInputs(timed(tp, window, func(_ string, t time.Time) {
+		c.Add(t)
+	}))
+	if err != nil {
+		return err
+	}
+
+	if *optCycle == "week" {
+		if *optRaw {
+			return c.Grid(*optAverage).PrintRaw()
+		}
+		return c.Grid(*optAverage).Print(shades)
+	}
+	if *optRaw {
+		return c.Table(*optAverage).PrintRaw()
+	}
+	return c.Table(*optAverage).Print(*optWidth)
+}
+
+// calendar counts the timestamps of input lines by date, then prints them as a
+// calendar of shaded cells.
+func calendar(tp *TimeParser, window TimeWindow, location *time.Location, shades shading) error {
+	c := NewCalendar(location)
+
+	err := ingestInputs(timed(tp, window, func(_ string, t time.Time) {
+		c.Add(t)
+	}))
+	if err != nil {
+		return err
+	}
+
+	if *optRaw {
+		return c.Table().PrintRaw()
+	}
+	return c.Print(shades)
+}
+
+// heatmap bins the numeric value of each input line by the bucket of time of
+// its timestamp, then prints them as a grid of shaded cells.
+func heatmap(tp *TimeParser, window TimeWindow, location *time.Location, shades shading) error {
+	values, err := NewFieldSplitter(*optValue, *optDelimiter)
+	if err != nil {
+		return err
+	}
+
+	bucket := *optBucket
+	if bucket == 0 {
+		bucket = heatmapBuckets[0] // finest resolution, until fit to width
+	}
+	h := NewHeatmap(bucket, location)
+
+	err = ingestInputs(timed(tp, window, func(line string, t time.Time) {
+		v, err := strconv.ParseFloat(values.Select(line), 64)
+		if err != nil {
+			warning("cannot parse value: %s", err)
+			return
+		}
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			warning("cannot use non-finite value: %g", v)
+			return
+		}
+		h.Add(t, v)
+	}))
+	if err != nil {
+		return err
+	}
+
+	if *optBucket == 0 {
+		// Leave room for the row labels and the space following them.
+		columns := *optWidth - h.LabelWidth() - 1
+		if *optRaw {
+			columns = rawHeatmapColumns
+		}
+		h = h.Fit(columns)
+	}
+	if *optRaw {
+		return h.Grid("2006-01-02T15:04:05").PrintRaw()
+	}
+	return h.Grid("").Print(shades)
+}
+
+// interarrival bins the time elapsed between the timestamps of successive input
+// lines, or between successive lines having the same key when --field
+// is given, then prints them.
+func interarrival(tp *TimeParser, window TimeWindow, fs *FieldSplitter) error {
+	ia := NewInterArrival()
+
+	err := ingestInputs(timed(tp, window, func(line string, t time.Time) {
+		var key string
+		if *optField != "" {
+			key = fs.Select(line)
+		}
+		ia.Add(key, t)
+	}))
+	if err != nil {
+		return err
+	}
+	if ia.OutOfOrder > 0 {
+		warning("%d timestamps earlier than their predecessor counted as zero gaps", ia.OutOfOrder)
+	}
+
+	if *optRaw {
+		return ia.Table().PrintRaw()
+	}
+	return ia.Table().Print(*optWidth)
+}
+
+// pair matches lines starting events with lines ending events by their
+// identifier, then prints the distribution of time elapsed between them.
+func pair(tp *TimeParser, window TimeWindow) error {
+	ids, err := NewFieldSplitter(*optPairID, *optDelimiter)
+	if err != nil {
+		return err
+	}
+	p := NewPairer(*optPairTimeout, *optPairMax)
+
+	callback := timed(tp, window, func(line string, t time.Time) {
+		id := ids.Select(line)
+		if id == "" {
+			warning("cannot find identifier: %q", line)
+			return
+		}
+		if containsWord(line, *optPairStart) {
+			p.Start(id, t)
+		} else {
+			p.End(id, t)
+		}
+	})
+
+	err = ingestInputs(func(line string) bool {
+		if !containsWord(line, *optPairStart) && !containsWord(line, *optPairEnd) {
+			return true // neither starts nor ends an event
+		}
+		return callback(line)
+	})
+	if err != nil {
+		return err
+	}
+	p.Finish()
+
+	if p.UnmatchedStarts > 0 || p.UnmatchedEnds > 0 || p.Abandoned > 0 {
+		warning("%d unmatched starts; %d unmatched ends; %d abandoned starts", p.UnmatchedStarts, p.UnmatchedEnds, p.Abandoned)
+	}
+
+	if *optRaw {
+		return p.Table().PrintRaw()
+	}
+	return p.Table().Print(*optWidth)
+}
+
+// rate counts each key along with its earliest and latest timestamps, then
+// prints the count, span of time, and rate of each key.
+func rate(tp *TimeParser, window TimeWindow, fs *FieldSplitter) error {
+	r := NewRates()
+
+	err := ingestInputs(timed(tp, window, func(line string, t time.Time) {
+		if key := fs.Select(line); len(key) > 0 {
+			r.Add(key, t)
+		}
+	}))
+	if err != nil {
+		return err
+	}
+
+	t := r.Table(*optRateBar)
+	if *optSortDesc {
+		t.SortDescending()
+	} else if *optSortAsc {
+		t.SortAscending()
+	}
+
+	if *optRaw {
+		return t.PrintRaw()
+	}
+	return t.Print(*optWidth)
+}
+
+// crosstab counts input lines by the combination of their --rows and --cols
+// keys, then prints the contingency table.
+func crosstab(tp *TimeParser, window TimeWindow, shades shading) error {
+	rows, err := NewFieldSplitter(*optRows, *optDelimiter)
+	if err != nil {
+		return err
+	}
+	cols, err := NewFieldSplitter(*optCols, *optDelimiter)
+	if err != nil {
+		return err
+	}
+	c := NewCrosstab()
+
+	err = ingestInputs(filtered(tp, window, func(line string) {
+		row, col := rows.Select(line), cols.Select(line)
+		if len(row) > 0 && len(col) > 0 {
+			c.Add(row, col)
+		}
+	}))
+	if err != nil {
+		return err
+	}
+
+	percent, format := percentNone, formatCount
+	if *optRowPercent {
+		percent, format = percentRow, formatPercent
+	} else if *optColPercent {
+		percent, format = percentCol, formatPercent
+	}
+
+	if *optRaw {
+		return c.Grid(true, percent).PrintRaw()
+	}
+	if *optShaded {
+		// Totals would dwarf every other cell, so are omitted.
+		return c.Grid(false, percent).Print(shades)
+	}
+	return c.Grid(true, percent).PrintValues(format)
+}
+
+// groupBy counts the key of each input line within the group selected by
+// --group-by, then prints a histogram for each group.
+func groupBy(tp *TimeParser, window TimeWindow, fs *FieldSplitter) error {
+	groups, err := NewFieldSplitter(*optGroupBy, *optDelimiter)
+	if err != nil {
+		return err
+	}
+	gs := NewGroups()
+
+	err = ingestInputs(filtered(tp, window, func(line string) {
+		name, key := groups.Select(line), fs.Select(line)
+		if len(name) > 0 && len(key) > 0 {
+			gs.Add(name, key)
+		}
+	}))
+	if err != nil {
+		return err
+	}
+
+	tables := gs.Tables(*optGroupTop)
+	for _, t := range tables {
+		if *optSortDesc {
+			t.SortDescending()
+		} else if *optSortAsc {
+			t.SortAscending()
+		}
+	}
+
+	if *optRaw {
+		// Keys of every group are listed together, each followed by the name
+		// of its group.
+		names := gs.Names()
+		raw := newTable("Key", "Count", "Group")
+		for i, t := range tables {
+			for j, row := range t.rows {
+				raw.Append(t.bars[j], row[0], row[1], names[i])
+			}
+		}
+		return raw.PrintRaw()
+	}
+	return printSections(gs.Titles(*optGroupTop), tables, *optWidth, *optGlobalScale)
+}
+
+// stack counts the key of each input line split by its --stack key, then
+// prints a bar for each key divided into a segment for each --stack key.
+func stack(tp *TimeParser, window TimeWindow, fs *FieldSplitter, shades shading) error {
+	segments, err := NewFieldSplitter(*optStack, *optDelimiter)
+	if err != nil {
+		return err
+	}
+	c := NewCrosstab()
+
+	err = ingestInputs(filtered(tp, window, func(line string) {
+		key, segment := fs.Select(line), segments.Select(line)
+		if len(key) > 0 && len(segment) > 0 {
+			c.Add(key, segment)
+		}
+	}))
+	if err != nil {
+		return err
+	}
+
+	g := c.Grid(false, percentNone)
+	if *optSortDesc {
+		g.SortDescending()
+	} else if *optSortAsc {
+		g.SortAscending()
+	}
+
+	if *optRaw {
+		return g.PrintRaw()
+	}
+	return g.PrintStacked(*optWidth, shades)
+}
+
+// perFile counts the key of each input line separately for each input, then
+// prints the count of each key in each input along with their total.
+func perFile(tp *TimeParser, window TimeWindow, fs *FieldSplitter, shades shading) error {
+	pathnames := golf.Args()
+	if len(pathnames) == 0 {
+		pathnames = []string{"-"}
+	}
+
+	c := NewCrosstab()
+	for _, pathname := range pathnames {
+		c.Column(pathname) // inputs without any keys still have a column
+	}
+	for _, pathname := range pathnames {
+		pathname := pathname
+		err := ingestFile(pathname, filtered(tp, window, func(line string) {
+			if key := fs.Select(line); len(key) > 0 {
+				c.Add(key, pathname)
+			}
+		}))
+		if err != nil {
+			return err
+		}
+	}
+
+	g := c.Grid(false, percentNone)
+	if *optSortDesc {
+		g.SortDescending()
+	} else if *optSortAsc {
+		g.SortAscending()
+	}
+
+	if *optRaw {
+		return g.Table().PrintRaw()
+	}
+	if *optGrouped {
+		return g.PrintGrouped(*optWidth, shades)
+	}
+	return g.Table().Print(*optWidth)
+}
+
+// diff counts the key of each line of the two inputs separately, then prints
+// the change in count of each key from the first input to the second.
+func diff(tp *TimeParser, window TimeWindow, fs *FieldSplitter) error {
+	d := NewDiff()
+
+	for i, pathname := range golf.Args() {
+		b := i == 1
+		err := ingestFile(pathname, filtered(tp, window, func(line string) {
+			if key := fs.Select(line); len(key) > 0 {
+				d.Add(key, b)
+			}
+		}))
+		if err != nil {
+			return err
+		}
+	}
+
+	// Keys which changed the most are the most interesting, so are listed
+	// first unless requested otherwise.
+	t := d.Table(golf.Arg(0), golf.Arg(1))
+	if *optSortAsc {
+		t.SortMagnitudeAscending()
+	} else {
+		t.SortMagnitudeDescending()
+	}
+
+	if *optRaw {
+		return t.PrintRaw()
+	}
+	return t.PrintDiverging(*optWidth)
+}
+
+// distinct counts the distinct --distinct values of each key of the input
+// lines, then prints them.
+func distinct(tp *TimeParser, window TimeWindow, fs *FieldSplitter) error {
+	values, err := NewFieldSplitter(*optDistinct, *optDelimiter)
+	if err != nil {
+		return err
+	}
+	d := NewDistinct(*optExact)
+
+	err = ingestInputs(filtered(tp, window, func(line string) {
+		key, value := fs.Select(line), values.Select(line)
+		if len(key) > 0 && len(value) > 0 {
+			d.Add(key, value)
+		}
+	}))
+	if err != nil {
+		return err
+	}
+	if !*optExact {
+		verbose("distinct counts are estimates having a standard error of %.1f%%", 104/math.Sqrt(1<<hllPrecision))
+	}
+
+	t := d.Table()
+	if *optSortDesc {
+		t.SortDescending()
+	} else if *optSortAsc {
+		t.SortAscending()
+	}
+
+	if *optRaw {
+		return t.PrintRaw()
+	}
+	return t.Print(*optWidth)
+}
+
+// spaceSavingFactor is the number of keys monitored for each key requested by
+// --top when estimating their counts. The count of each key shown may
+// overestimate its true count by at most the total count divided by the
+// number of keys monitored.
+const spaceSavingFactor = 10
+
+// approximate estimates the counts of the --top most frequent keys of the
+// input lines in fixed memory, then prints them.
+func approximate(tp *TimeParser, window TimeWindow, fs *FieldSplitter) error {
+	ss := NewSpaceSaving(spaceSavingFactor * *optTop)
+
+	if err := ingestInputs(filtered(tp, window, counted(fs, ss.AddCount))); err != nil {
+		return err
+	}
+	verbose("estimated counts of %d lines may each be overestimated by at most %d", ss.Total(), ss.Total()/(spaceSavingFactor**optTop))
+
+	// The most frequent keys are listed first unless requested otherwise.
+	t := ss.Table(*optTop)
+	if *optSortAsc {
+		t.SortAscending()
+	}
+
+	if *optRaw {
+		return t.PrintRaw()
+	}
+	return t.Print(*optWidth)
+}
+
+// countMin estimates the counts of the keys of the input lines using a
+// Count-Min sketch, merged with any sketches given by --load, optionally saves
+// the sketch, then prints the estimated count of each key listed in the
+// --query file.
+func countMin(tp *TimeParser, window TimeWindow, fs *FieldSplitter) error {
+	cm, err := NewCountMin(*optEpsilon, *optDelta)
+	if err != nil {
+		return err
+	}
+
+	if *optLoad != "" {
+		pathnames, err := statePathnames(*optLoad)
+		if err != nil {
+			return err
+		}
+		for _, pathname := range pathnames {
+			other, options, err := LoadCountMin(pathname)
+			if err != nil {
+				return err
+			}
+			checkStateOptions(pathname, options)
+			if err = cm.Merge(other); err != nil {
+				return fmt.Errorf("cannot load state: %s: %s", pathname, err)
+			}
+		}
+	}
+
+	// When loading sketches, only read input when it is named, so the saved
+	// sketches may be queried without waiting on standard input.
+	if *optLoad == "" || golf.NArg() > 0 {
+		if err = ingestInputs(filtered(tp, window, counted(fs, cm.AddCount))); err != nil {
+			return err
+		}
+	}
+
+	if *optSave != "" {
+		if err = SaveCountMin(*optSave, cm, stateOptions()); err != nil {
+			return err
+		}
+	}
+
+	if *optQuery == "" {
+		return nil
+	}
+
+	t := newTable("Key", "Count")
+	err = ingestFile(*optQuery, func(key string) bool {
+		estimate := cm.Estimate(key)
+		t.Append(float64(estimate), key, strconv.Itoa(estimate))
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	verbose("estimated counts of %d lines exceed true counts by at most %d with probability %g", cm.Total(), cm.Bound(), 1-*optDelta)
+
+	if *optSortDesc {
+		t.SortDescending()
+	} else if *optSortAsc {
+		t.SortAscending()
+	}
+
+	if *optRaw {
+		return t.PrintRaw()
+	}
+	return t.Print(*optWidth)
+}
+
+// spilled counts the key of each input line exactly, spilling counts to
+// temporary files whenever the keys held in memory exceed --max-memory, then
+// prints the count of each key in the same layouts as the other histograms.
+func spilled(tp *TimeParser, window TimeWindow, fs *FieldSplitter) error {
+	budget, err := parseSize(*optMaxMemory)
+	if err != nil {
+		return err
+	}
+	sc, err := NewSpillCounter(budget)
+	if err != nil {
+		return err
+	}
+	defer sc.Close()
+
+	if err = ingestInputs(filtered(tp, window, counted(fs, sc.AddCount))); err != nil {
+		return err
+	}
+
+	less := spillByFirst
+	if *optSortDesc {
+		less = spillByCountDescending
+	} else if *optSortAsc {
+		less = spillByCountAscending
+	}
+
+	t := newTable("Key", "Count")
+	width := *optWidth
+	if *optPercent {
+		t = newTable("Key", "Count", "Percent")
+		// Match the layout of gohistogram.Strings, which does not keep
+		// the bars from the final column when showing percentages.
+		width++
+	}
+
+	// The widths of the columns and the scale of the bars are only known
+	// once every key has been folded, but are required before printing
+	// the first row.
+	var keyWidth, max, total int
+	folded := func(r *spillRecord) {
+		if keyWidth < len(r.key) {
+			keyWidth = len(r.key)
+		}
+		if max < r.count {
+			max = r.count
+		}
+		total += r.count
+	}
+
+	var widths []int
+	var barWidth int
+	err = sc.Sort(less, folded, func(r *spillRecord) error {
+		if *optRaw {
+			_, err := fmt.Printf("%*d %s\n", len(strconv.Itoa(max)), r.count, r.key)
+			return err
+		}
+		cells := []string{r.key, strconv.Itoa(r.count)}
+		if *optPercent {
+			cells = append(cells, fmt.Sprintf("% 7.2f", 100*float64(r.count)/float64(total)))
+		}
+		if widths == nil {
+			widths = t.widths()
+			if widths[0] < keyWidth {
+				widths[0] = keyWidth
+			}
+			if n := len(strconv.Itoa(max)); widths[1] < n {
+				widths[1] = n
+			}
+			var err error
+			if barWidth, err = t.printHeader(widths, width, float64(max)); err != nil {
+				return err
+			}
+		}
+		return t.printRow(widths, barWidth, float64(max), float64(r.count), cells)
+	})
+	if sc.Spills() > 0 {
+		verbose("spilled counts of %d lines to temporary files %d times", total, sc.Spills())
+	}
+	return err
+}
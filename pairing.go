@@ -0,0 +1,149 @@
+package main
+
+import (
+	"container/list"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// defaultPairMax is the default number of start events waiting for their end
+// events, beyond which the oldest are abandoned.
+const defaultPairMax = 100000
+
+// pendingStart is a start event waiting for its matching end event.
+type pendingStart struct {
+	id   string
+	when time.Time
+}
+
+// Pairer matches start events with end events by their correlation identifier,
+// and bins the time elapsed between them. In order to bound memory, start
+// events which have not been matched within a timeout, or which exceed the
+// maximum number of pending start events, are abandoned.
+type Pairer struct {
+	bins       *LogBins
+	timeout    time.Duration            // when positive, abandon start events older than this
+	maxPending int                      // when positive, abandon oldest start events beyond this many
+	pending    map[string]*list.Element // pending start events by identifier
+	order      *list.List               // pending start events, oldest first
+	latest     time.Time                // latest timestamp of any event
+
+	UnmatchedStarts int // start events never matched by an end event
+	UnmatchedEnds   int // end events without a pending start event
+	Abandoned       int // start events abandoned because of timeout or memory bound
+}
+
+// NewPairer returns a Pairer that abandons start events which have waited
+// longer than timeout for their end event, or which are the oldest of more
+// than maxPending start events. A zero timeout or maxPending disables the
+// respective limit.
+func NewPairer(timeout time.Duration, maxPending int) *Pairer {
+	return &Pairer{
+		bins:       NewLogBins(),
+		timeout:    timeout,
+		maxPending: maxPending,
+		pending:    make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+// Start records a start event having the specified identifier at time t.
+func (p *Pairer) Start(id string, t time.Time) {
+	p.expire(t)
+	if e, ok := p.pending[id]; ok {
+		// Another start event with the same identifier means the previous
+		// one will never be matched.
+		p.order.Remove(e)
+		p.UnmatchedStarts++
+	}
+	p.pending[id] = p.order.PushBack(&pendingStart{id: id, when: t})
+
+	if p.maxPending > 0 && p.order.Len() > p.maxPending {
+		p.abandon(p.order.Front())
+	}
+}
+
+// End records an end event having the specified identifier at time t, counting
+// the time elapsed since the matching start event.
+func (p *Pairer) End(id string, t time.Time) {
+	p.expire(t)
+	e, ok := p.pending[id]
+	if !ok {
+		p.UnmatchedEnds++
+		return
+	}
+	p.order.Remove(e)
+	delete(p.pending, id)
+	p.bins.Add(t.Sub(e.Value.(*pendingStart).when).Seconds())
+}
+
+// Finish counts all start events still waiting for their end event as
+// unmatched. It should be called after all events have been recorded.
+func (p *Pairer) Finish() {
+	p.UnmatchedStarts += p.order.Len()
+	p.pending = make(map[string]*list.Element)
+	p.order.Init()
+}
+
+// expire abandons start events which have waited longer than the timeout,
+// measured against the latest timestamp seen.
+func (p *Pairer) expire(t time.Time) {
+	if t.After(p.latest) {
+		p.latest = t
+	}
+	if p.timeout <= 0 {
+		return
+	}
+	cutoff := p.latest.Add(-p.timeout)
+	for e := p.order.Front(); e != nil && e.Value.(*pendingStart).when.Before(cutoff); e = p.order.Front() {
+		p.abandon(e)
+	}
+}
+
+// abandon stops waiting for the end event of the pending start event.
+func (p *Pairer) abandon(e *list.Element) {
+	p.order.Remove(e)
+	delete(p.pending, e.Value.(*pendingStart).id)
+	p.Abandoned++
+}
+
+// Table returns a table with a row for each log scale bin of elapsed time.
+func (p *Pairer) Table() *table {
+	return p.bins.Table("Elapsed", formatSeconds)
+}
+
+// containsWord returns true when the line contains the marker as a whole word,
+// so that a marker such as "end" is found in "request end" but not in
+// "backend" or "endpoint". Only edges of the marker which are word characters
+// must be at word boundaries.
+func containsWord(line, marker string) bool {
+	if marker == "" {
+		return false
+	}
+	first, _ := utf8.DecodeRuneInString(marker)
+	last, _ := utf8.DecodeLastRuneInString(marker)
+	for offset := 0; offset < len(line); {
+		i := strings.Index(line[offset:], marker)
+		if i < 0 {
+			return false
+		}
+		i += offset
+		j := i + len(marker)
+		before, _ := utf8.DecodeLastRuneInString(line[:i])
+		after, _ := utf8.DecodeRuneInString(line[j:])
+		if (i == 0 || !isWordRune(first) || !isWordRune(before)) &&
+			(j == len(line) || !isWordRune(last) || !isWordRune(after)) {
+			return true
+		}
+		_, size := utf8.DecodeRuneInString(line[i:])
+		offset = i + size
+	}
+	return false
+}
+
+// isWordRune returns true when r is a letter, digit, or underscore.
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPairerMatches(t *testing.T) {
+	p := NewPairer(0, 0)
+
+	start := time.Date(2019, 7, 4, 12, 0, 0, 0, time.UTC)
+	p.Start("1", start)
+	p.Start("2", start)
+	p.End("1", start.Add(time.Second))
+	p.End("3", start.Add(time.Second))
+	p.Finish()
+
+	if got, want := p.bins.Total(), 1; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := p.UnmatchedStarts, 1; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := p.UnmatchedEnds, 1; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestPairerTimeout(t *testing.T) {
+	p := NewPairer(time.Minute, 0)
+
+	start := time.Date(2019, 7, 4, 12, 0, 0, 0, time.UTC)
+	p.Start("1", start)
+	p.Start("2", start.Add(2*time.Minute))
+	p.End("1", start.Add(2*time.Minute))
+	p.Finish()
+
+	if got, want := p.Abandoned, 1; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := p.UnmatchedEnds, 1; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := p.UnmatchedStarts, 1; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestPairerMaxPending(t *testing.T) {
+	p := NewPairer(0, 2)
+
+	start := time.Date(2019, 7, 4, 12, 0, 0, 0, time.UTC)
+	p.Start("1", start)
+	p.Start("2", start)
+	p.Start("3", start)
+
+	if got, want := len(p.pending), 2; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := p.Abandoned, 1; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if _, ok := p.pending["1"]; ok {
+		t.Errorf("GOT: %v; WANT: %v", ok, false)
+	}
+}
+
+func TestPairerDuplicateStart(t *testing.T) {
+	p := NewPairer(0, 0)
+
+	start := time.Date(2019, 7, 4, 12, 0, 0, 0, time.UTC)
+	p.Start("1", start)
+	p.Start("1", start.Add(time.Second))
+	p.End("1", start.Add(3*time.Second))
+	p.Finish()
+
+	if got, want := p.UnmatchedStarts, 1; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := p.bins.counts[logBinIndex(2)], 1; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestContainsWord(t *testing.T) {
+	cases := []struct {
+		line, marker string
+		want         bool
+	}{
+		{"12:00 request end 7", "end", true},
+		{"end 7", "end", true},
+		{"12:00 7 end", "end", true},
+		{"12:00 end: 7", "end", true},
+		{"12:00 backend 7", "end", false},
+		{"12:00 endpoint 7", "end", false},
+		{"12:00 append 7", "end", false},
+		{"12:00 backend end 7", "end", true},
+		{"12:00 [start] 7", "[start]", true},
+		{"12:00 x[start]y 7", "[start]", true},
+		{"12:00 begin 7", "", false},
+	}
+	for _, c := range cases {
+		if got := containsWord(c.line, c.marker); got != c.want {
+			t.Errorf("%q %q: GOT: %v; WANT: %v", c.line, c.marker, got, c.want)
+		}
+	}
+}
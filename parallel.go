@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"sync"
+)
+
+// minChunkSize is the fewest bytes of a file ingested by a worker of the
+// parallel pipeline, below which splitting a file costs more than it saves.
+const minChunkSize = 1 << 20
+
+// chunk is a section of an input ingested by a single worker. Sections always
+// begin at the start of a line and end at the start of another line or at the
+// end of the input.
+type chunk struct {
+	pathname   string // "-" for standard input
+	start, end int64  // byte offsets of the section; end is zero for the entire input
+}
+
+// planChunks returns the chunks of the inputs, in order. Regular files are
+// split into sections of at least minSize bytes, aiming for several sections
+// per worker so that workers finishing early may take another. Standard input
+// and other files which cannot be split are each a single chunk.
+func planChunks(pathnames []string, jobs int, minSize int64) ([]chunk, error) {
+	if len(pathnames) == 0 {
+		return []chunk{{pathname: "-"}}, nil
+	}
+
+	sizes := make([]int64, len(pathnames))
+	var total int64
+	for i, pathname := range pathnames {
+		if pathname == "-" {
+			continue
+		}
+		fi, err := os.Stat(pathname)
+		if err != nil {
+			return nil, err
+		}
+		if fi.Mode().IsRegular() {
+			sizes[i] = fi.Size()
+			total += fi.Size()
+		}
+	}
+	size := total / int64(4*jobs)
+	if size < minSize {
+		size = minSize
+	}
+
+	var chunks []chunk
+	for i, pathname := range pathnames {
+		if sizes[i] <= size {
+			chunks = append(chunks, chunk{pathname: pathname})
+			continue
+		}
+		fh, err := os.Open(pathname)
+		if err != nil {
+			return nil, err
+		}
+		var start int64
+		for start < sizes[i] {
+			end := sizes[i]
+			if start+size < end {
+				if end, err = lineStart(fh, start+size); err != nil {
+					_ = fh.Close()
+					return nil, err
+				}
+			}
+			chunks = append(chunks, chunk{pathname: pathname, start: start, end: end})
+			start = end
+		}
+		if err = fh.Close(); err != nil {
+			return nil, err
+		}
+	}
+	return chunks, nil
+}
+
+// lineStart returns the offset of the first line of the file which starts at
+// or after offset, which must be within the file, or the size of the file
+// when there is none.
+func lineStart(fh *os.File, offset int64) (int64, error) {
+	if offset == 0 {
+		return 0, nil
+	}
+	// The line starts at offset when the preceding byte ends a line.
+	br := bufio.NewReader(io.NewSectionReader(fh, offset-1, 1<<62))
+	position := offset - 1
+	for {
+		buf, err := br.ReadSlice('\n')
+		position += int64(len(buf))
+		if err == nil || err == io.EOF {
+			return position, nil
+		}
+		if err != bufio.ErrBufferFull {
+			return 0, err
+		}
+	}
+}
+
+// ingestChunk invokes callback with the bytes of each non-empty line of the
+// chunk.
+func ingestChunk(c chunk, callback func([]byte) bool) error {
+	return ingestSection(c.pathname, c.start, c.end, callback)
+}
+
+// keyCounter counts keys, then replays them.
+type keyCounter interface {
+	AddCount(key string, count int)
+	AddBytes(key []byte, count int)
+	Each(callback func(key string, count int))
+}
+
+// keyRuns counts each run of adjacent identical keys, which is what
+// gohistogram.Strings records until its keys are folded.
+type keyRuns struct {
+	items []counterItem
+}
+
+// AddCount counts the key count times, extending the final run when it has
+// the same key.
+func (r *keyRuns) AddCount(key string, count int) {
+	if n := len(r.items); n > 0 && r.items[n-1].key == key {
+		r.items[n-1].count += count
+		return
+	}
+	r.items = append(r.items, counterItem{key: key, count: count})
+}
+
+// AddBytes counts the key count times. It only allocates when the key begins
+// a new run, so a buffer may be reused for each key.
+func (r *keyRuns) AddBytes(key []byte, count int) {
+	if n := len(r.items); n > 0 && r.items[n-1].key == string(key) {
+		r.items[n-1].count += count
+		return
+	}
+	r.items = append(r.items, counterItem{key: string(key), count: count})
+}
+
+// Each invokes callback with the key and count of each run, in order.
+func (r *keyRuns) Each(callback func(key string, count int)) {
+	for _, item := range r.items {
+		callback(item.key, item.count)
+	}
+}
+
+// ingestParallel counts the keys of the chunks using jobs workers, then
+// invokes add with each of them in the order of the chunks, so keys are added
+// in the same order as when the inputs are read serially. Each worker counts
+// a chunk using a Counter when folded is true, otherwise by recording its runs
+// of adjacent identical keys, and ingests its lines with the callback
+// returned by line for that counter. Chunks are added as soon as they and every chunk before
+// them are counted, so only the counts of chunks awaiting earlier chunks are
+// held in memory at once.
+func ingestParallel(chunks []chunk, jobs int, folded bool, line func(kc keyCounter) func([]byte) bool, add func(key string, count int)) error {
+	counts := make([]keyCounter, len(chunks))
+	errs := make([]error, len(chunks))
+
+	indexes := make(chan int)
+	done := make(chan int)
+	go func() {
+		for i := range chunks {
+			indexes <- i
+		}
+		close(indexes)
+	}()
+
+	var wg sync.WaitGroup
+	for w := 0; w < jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				var kc keyCounter = new(keyRuns)
+				if folded {
+					kc = NewCounter()
+				}
+				errs[i] = ingestChunk(chunks[i], line(kc))
+				counts[i] = kc
+				done <- i
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	ready := make([]bool, len(chunks))
+	var next int
+	var err error
+	for i := range done {
+		ready[i] = true
+		for ; next < len(chunks) && ready[next]; next++ {
+			if err == nil {
+				err = errs[next]
+			}
+			if err == nil {
+				counts[next].Each(add)
+			}
+			counts[next] = nil
+		}
+	}
+	return err
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// writeLines writes a file of lines in a new temporary directory, returning
+// its pathname and a function removing the directory.
+func writeLines(t *testing.T, lines []string) (string, func()) {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "histogram")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pathname := filepath.Join(dir, "input.log")
+	if err = ioutil.WriteFile(pathname, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return pathname, func() { os.RemoveAll(dir) }
+}
+
+func testLines() []string {
+	var lines []string
+	for i := 0; i < 300; i++ {
+		key := []string{"a", "a", "b", strings.Repeat("c", 40)}[i*7%11%4]
+		lines = append(lines, strconv.Itoa(i)+" "+key)
+	}
+	return lines
+}
+
+func TestPlanChunks(t *testing.T) {
+	lines := testLines()
+	pathname, remove := writeLines(t, lines)
+	defer remove()
+
+	chunks, err := planChunks([]string{pathname}, 4, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(chunks) < 2 {
+		t.Fatalf("GOT: %v; WANT: >= %v", len(chunks), 2)
+	}
+	var got []string
+	var start int64
+	for _, c := range chunks {
+		if c.start != start {
+			t.Errorf("GOT: %v; WANT: %v", c.start, start)
+		}
+		start = c.end
+		err = ingestChunk(c, text(func(line string) bool {
+			got = append(got, line)
+			return true
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := len(got), len(lines); got != want {
+		t.Fatalf("GOT: %v; WANT: %v", got, want)
+	}
+	for i := range lines {
+		if got[i] != lines[i] {
+			t.Errorf("%d: GOT: %q; WANT: %q", i, got[i], lines[i])
+		}
+	}
+}
+
+func TestPlanChunksSmallFile(t *testing.T) {
+	pathname, remove := writeLines(t, []string{"a", "b"})
+	defer remove()
+
+	chunks, err := planChunks([]string{pathname, "-"}, 4, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(chunks), 2; got != want {
+		t.Fatalf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := chunks[0], (chunk{pathname: pathname}); got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestIngestParallelMatchesSerial(t *testing.T) {
+	pathname, remove := writeLines(t, testLines())
+	defer remove()
+	fs, err := NewFieldSplitter("2", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, folded := range []bool{false, true} {
+		var kc keyCounter = new(keyRuns)
+		if folded {
+			kc = NewCounter()
+		}
+		err = ingestFile(pathname, func(line string) bool {
+			kc.AddCount(fs.Select(line), 1)
+			return true
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var want []counterItem
+		kc.Each(func(key string, count int) { want = append(want, counterItem{key, count}) })
+
+		chunks, err := planChunks([]string{pathname}, 3, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		kc = new(keyRuns)
+		if folded {
+			kc = NewCounter()
+		}
+		err = ingestParallel(chunks, 3, folded, func(chunk keyCounter) func([]byte) bool {
+			return countedBytes(fs, chunk)
+		}, kc.AddCount)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []counterItem
+		kc.Each(func(key string, count int) { got = append(got, counterItem{key, count}) })
+
+		if len(got) != len(want) {
+			t.Fatalf("folded %v: GOT: %v; WANT: %v", folded, len(got), len(want))
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("folded %v: %d: GOT: %v; WANT: %v", folded, i, got[i], want[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// splitCounted splits a line of pre-counted input into its count and the
+// remainder of the line, such as the lines printed by `uniq -c`, or by this
+// program with --raw. When last is false, the count is the first field of the
+// line, otherwise it is the final field. Fields are separated by delimiter,
+// or by whitespace when delimiter is empty.
+func splitCounted(line string, last bool, delimiter string) (int, string, error) {
+	var count, rest string
+	if delimiter == "" {
+		line = strings.TrimSpace(line)
+		if last {
+			i := strings.LastIndexFunc(line, unicode.IsSpace)
+			count, rest = line[i+1:], line[:i+1]
+		} else {
+			i := strings.IndexFunc(line, unicode.IsSpace)
+			if i == -1 {
+				i = len(line)
+			}
+			count, rest = line[:i], line[i:]
+		}
+		rest = strings.TrimSpace(rest)
+	} else if last {
+		i := strings.LastIndex(line, delimiter)
+		if i == -1 {
+			count = line
+		} else {
+			count, rest = line[i+len(delimiter):], line[:i]
+		}
+	} else {
+		i := strings.Index(line, delimiter)
+		if i == -1 {
+			count = line
+		} else {
+			count, rest = line[:i], line[i+len(delimiter):]
+		}
+	}
+
+	n, err := strconv.Atoi(count)
+	if err != nil || n < 0 {
+		return 0, "", fmt.Errorf("cannot parse count: %q", line)
+	}
+	return n, rest, nil
+}
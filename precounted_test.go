@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestSplitCounted(t *testing.T) {
+	cases := []struct {
+		line      string
+		last      bool
+		delimiter string
+		count     int
+		rest      string
+	}{
+		{"      3 GET /index.html", false, "", 3, "GET /index.html"},
+		{"12 a", false, "", 12, "a"},
+		{"7", false, "", 7, ""},
+		{"GET /index.html 42", true, "", 42, "GET /index.html"},
+		{"/index.html\t5", true, "", 5, "/index.html"},
+		{"9,a,b", false, ",", 9, "a,b"},
+		{"a,b,9", true, ",", 9, "a,b"},
+	}
+
+	for _, c := range cases {
+		count, rest, err := splitCounted(c.line, c.last, c.delimiter)
+		if err != nil {
+			t.Errorf("%q GOT: %v; WANT: %v", c.line, err, nil)
+			continue
+		}
+		if got, want := count, c.count; got != want {
+			t.Errorf("%q GOT: %v; WANT: %v", c.line, got, want)
+		}
+		if got, want := rest, c.rest; got != want {
+			t.Errorf("%q GOT: %q; WANT: %q", c.line, got, want)
+		}
+	}
+}
+
+func TestSplitCountedRejects(t *testing.T) {
+	for _, line := range []string{"GET 3", "-3 GET", "3.5 GET"} {
+		if _, _, err := splitCounted(line, false, ""); err == nil {
+			t.Errorf("%q GOT: %v; WANT: %v", line, err, "non-nil")
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"strconv"
+	"time"
+)
+
+type rateItem struct {
+	key         string
+	count       int
+	first, last time.Time // earliest and latest timestamps of key
+}
+
+// Rates tracks the number of times each key is seen, along with the earliest
+// and latest timestamps of the key, in order to compute the rate at which each
+// key occurs over the span of time it was observed. Keys are kept in the order
+// they were first seen.
+type Rates struct {
+	items   []*rateItem
+	indexes map[string]int // index into items of each key
+}
+
+// NewRates returns an empty Rates.
+func NewRates() *Rates {
+	return &Rates{indexes: make(map[string]int)}
+}
+
+// Add counts the key seen at time t.
+func (r *Rates) Add(key string, t time.Time) {
+	i, ok := r.indexes[key]
+	if !ok {
+		r.indexes[key] = len(r.items)
+		r.items = append(r.items, &rateItem{key: key, count: 1, first: t, last: t})
+		return
+	}
+	item := r.items[i]
+	item.count++
+	if t.Before(item.first) {
+		item.first = t
+	} else if t.After(item.last) {
+		item.last = t
+	}
+}
+
+// Table returns a table with a row for each key showing its count, the span
+// of time between its earliest and latest timestamps, and the number of times
+// it occurred per second over that span. Keys seen only at a single instant
+// have no rate. When byRate is true, bars show the rate rather than the count.
+func (r *Rates) Table(byRate bool) *table {
+	t := newTable("Key", "Count", "Span", "Rate/s")
+	for _, item := range r.items {
+		span := item.last.Sub(item.first)
+		var rate float64
+		formattedRate := "-"
+		if span > 0 {
+			rate = float64(item.count) / span.Seconds()
+			formattedRate = strconv.FormatFloat(rate, 'g', 4, 64)
+		}
+		bar := float64(item.count)
+		if byRate {
+			bar = rate
+		}
+		t.Append(bar, item.key, strconv.Itoa(item.count), span.Round(time.Millisecond).String(), formattedRate)
+	}
+	return t
+}
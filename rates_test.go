@@ -0,0 +1,58 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRates(t *testing.T) {
+	r := NewRates()
+
+	start := time.Date(2019, 7, 4, 12, 0, 0, 0, time.UTC)
+	r.Add("b", start.Add(10*time.Second))
+	r.Add("a", start)
+	r.Add("b", start)
+	r.Add("b", start.Add(5*time.Second))
+
+	tab := r.Table(false)
+
+	if got, want := len(tab.rows), 2; got != want {
+		t.Fatalf("GOT: %v; WANT: %v", got, want)
+	}
+
+	// first seen order
+	if got, want := tab.rows[0][0], "b"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := tab.rows[0][1], "3"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := tab.rows[0][2], "10s"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := tab.rows[0][3], "0.3"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := tab.bars[0], 3.0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+
+	// single instant has no rate
+	if got, want := tab.rows[1][3], "-"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestRatesBarByRate(t *testing.T) {
+	r := NewRates()
+
+	start := time.Date(2019, 7, 4, 12, 0, 0, 0, time.UTC)
+	r.Add("a", start)
+	r.Add("a", start.Add(4*time.Second))
+
+	tab := r.Table(true)
+
+	if got, want := tab.bars[0], 0.5; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
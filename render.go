@@ -0,0 +1,717 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// table is a list of rows, each having a key, zero or more value columns, and
+// a bar of stars whose length is proportional to the row's bar value. It
+// prints in the same layout gohistogram.Strings uses, so modes which need
+// columns other than Count look like the rest of the program's output.
+type table struct {
+	headers []string   // column headers, the first of which is for the key column
+	rows    [][]string // formatted cells of each row, in header order
+	bars    []float64  // drives the length of the bar of each row
+}
+
+// newTable returns a table with the specified column headers. The first header
+// names the key column.
+func newTable(headers ...string) *table {
+	return &table{headers: headers}
+}
+
+// Append adds a row to the table with the specified bar value and cells.
+func (t *table) Append(bar float64, cells ...string) {
+	t.rows = append(t.rows, cells)
+	t.bars = append(t.bars, bar)
+}
+
+// widths returns the number of columns required to display each column of the
+// table, including its header.
+func (t *table) widths() []int {
+	widths := make([]int, len(t.headers))
+	for i, h := range t.headers {
+		widths[i] = len(h)
+	}
+	for _, row := range t.rows {
+		for i, cell := range row {
+			if widths[i] < len(cell) {
+				widths[i] = len(cell)
+			}
+		}
+	}
+	return widths
+}
+
+// Print displays the table with its key column, its value columns, and a
+// histogram of stars scaled to fit within width columns.
+func (t *table) Print(width int) error {
+	return t.print(t.widths(), width, t.barMax())
+}
+
+// barMax returns the largest bar value of any row.
+func (t *table) barMax() float64 {
+	var barMax float64
+	for _, b := range t.bars {
+		if barMax < b {
+			barMax = b
+		}
+	}
+	return barMax
+}
+
+// print displays the table using the specified column widths, with bars
+// scaled so a bar value of barMax fills the remainder of width columns.
+func (t *table) print(widths []int, width int, barMax float64) error {
+	if len(t.rows) == 0 {
+		return nil
+	}
+
+	barWidth, err := t.printHeader(widths, width, barMax)
+	if err != nil {
+		return err
+	}
+	for i, row := range t.rows {
+		if err = t.printRow(widths, barWidth, barMax, t.bars[i], row); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// printHeader displays the column headers using the specified column widths,
+// and returns the number of columns remaining for the bars.
+func (t *table) printHeader(widths []int, width int, barMax float64) (int, error) {
+	used := 1 // plus 1 to keep from final column
+	for _, w := range widths {
+		used += w + 1 // column plus the space that follows it
+	}
+	barWidth := width - used
+	if barWidth < 1 {
+		return 0, fmt.Errorf("cannot print with fewer than %d columns", used+1)
+	}
+	_, err := fmt.Printf("%s(~%.3g per *)\n", t.format(widths, t.headers), barMax/float64(barWidth))
+	return barWidth, err
+}
+
+// printRow displays the cells of a row using the specified column widths,
+// followed by a bar scaled so a bar value of barMax fills barWidth columns.
+func (t *table) printRow(widths []int, barWidth int, barMax, bar float64, cells []string) error {
+	var w int
+	if barMax > 0 {
+		w = int(float64(barWidth) * bar / barMax)
+	}
+	_, err := fmt.Printf("%s%s\n", t.format(widths, cells), strings.Repeat("*", w))
+	return err
+}
+
+// PrintRaw displays the value columns of the table followed by its key column,
+// without a header or histogram. Like the raw output of a Counter, each
+// column is only as wide as its widest value.
+func (t *table) PrintRaw() error {
+	widths := make([]int, len(t.headers))
+	for _, row := range t.rows {
+		for i, cell := range row {
+			if widths[i] < len(cell) {
+				widths[i] = len(cell)
+			}
+		}
+	}
+	for _, row := range t.rows {
+		var sb strings.Builder
+		for i := 1; i < len(row); i++ {
+			fmt.Fprintf(&sb, "%*s ", widths[i], row[i])
+		}
+		sb.WriteString(row[0])
+		if _, err := fmt.Println(sb.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// format returns the cells left aligning the key and right aligning the
+// values, each followed by a space.
+func (t *table) format(widths []int, cells []string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%-*s ", widths[0], cells[0])
+	for i := 1; i < len(cells); i++ {
+		fmt.Fprintf(&sb, "%*s ", widths[i], cells[i])
+	}
+	return sb.String()
+}
+
+// Len returns the number of rows in the table.
+func (t *table) Len() int { return len(t.rows) }
+
+// Less returns true when the bar of row i is shorter than the bar of row j.
+func (t *table) Less(i, j int) bool { return t.bars[i] < t.bars[j] }
+
+// Swap exchanges rows i and j.
+func (t *table) Swap(i, j int) {
+	t.rows[i], t.rows[j] = t.rows[j], t.rows[i]
+	t.bars[i], t.bars[j] = t.bars[j], t.bars[i]
+}
+
+// SortAscending orders rows by increasing bar value, preserving the order of
+// rows with equal bar values.
+func (t *table) SortAscending() { sort.Stable(t) }
+
+// SortDescending orders rows by decreasing bar value, preserving the order of
+// rows with equal bar values.
+func (t *table) SortDescending() { sort.Stable(sort.Reverse(t)) }
+
+// magnitudes sorts the rows of a table by the magnitude of their bar values,
+// for tables whose bar values may be negative.
+type magnitudes struct{ *table }
+
+func (t magnitudes) Less(i, j int) bool { return math.Abs(t.bars[i]) < math.Abs(t.bars[j]) }
+
+// SortMagnitudeAscending orders rows by increasing magnitude of bar value,
+// preserving the order of rows with equal magnitudes.
+func (t *table) SortMagnitudeAscending() { sort.Stable(magnitudes{t}) }
+
+// SortMagnitudeDescending orders rows by decreasing magnitude of bar value,
+// preserving the order of rows with equal magnitudes.
+func (t *table) SortMagnitudeDescending() { sort.Stable(sort.Reverse(magnitudes{t})) }
+
+// PrintDiverging displays the table with its key column, its value columns,
+// and a histogram whose bars extend left of a center axis for negative bar
+// values, and right of it for positive bar values, scaled to fit within width
+// columns.
+func (t *table) PrintDiverging(width int) error {
+	if len(t.rows) == 0 {
+		return nil
+	}
+
+	widths := t.widths()
+	used := 2 // center axis, plus 1 to keep from final column
+	for _, w := range widths {
+		used += w + 1 // column plus the space that follows it
+	}
+	half := (width - used) / 2
+	if half < 1 {
+		return fmt.Errorf("cannot print with fewer than %d columns", used+2)
+	}
+
+	var barMax float64
+	for _, b := range t.bars {
+		if barMax < math.Abs(b) {
+			barMax = math.Abs(b)
+		}
+	}
+
+	fmt.Printf("%s(~%.3g per - or +)\n", t.format(widths, t.headers), barMax/float64(half))
+	for i, row := range t.rows {
+		var w int
+		if barMax > 0 {
+			w = int(float64(half) * math.Abs(t.bars[i]) / barMax)
+		}
+		left, right := strings.Repeat(" ", half), ""
+		if t.bars[i] < 0 {
+			left = strings.Repeat(" ", half-w) + strings.Repeat("-", w)
+		} else {
+			right = strings.Repeat("+", w)
+		}
+		if _, err := fmt.Printf("%s%s|%s\n", t.format(widths, row), left, right); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Top removes all but the n rows having the largest bar values, preserving
+// the order of the rows which remain. Rows with equal bar values are kept in
+// the order they appear.
+func (t *table) Top(n int) {
+	if n >= len(t.rows) {
+		return
+	}
+	order := make([]int, len(t.rows))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return t.bars[order[i]] > t.bars[order[j]] })
+	sort.Ints(order[:n])
+
+	rows := make([][]string, n)
+	bars := make([]float64, n)
+	for i, o := range order[:n] {
+		rows[i], bars[i] = t.rows[o], t.bars[o]
+	}
+	t.rows, t.bars = rows, bars
+}
+
+// printSections displays each table preceded by its title, with columns
+// aligned across all tables. When global is true, bars of every table are
+// scaled against the largest bar of any table so they may be compared,
+// rather than against the largest bar of their own table.
+func printSections(titles []string, tables []*table, width int, global bool) error {
+	var widths []int
+	var barMax float64
+	for _, t := range tables {
+		for i, w := range t.widths() {
+			if i == len(widths) {
+				widths = append(widths, w)
+			} else if widths[i] < w {
+				widths[i] = w
+			}
+		}
+		if m := t.barMax(); barMax < m {
+			barMax = m
+		}
+	}
+
+	for i, t := range tables {
+		if i > 0 {
+			if _, err := fmt.Println(); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Println(titles[i]); err != nil {
+			return err
+		}
+		m := barMax
+		if !global {
+			m = t.barMax()
+		}
+		if err := t.print(widths, width, m); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// shading is the style used to display the cells of a grid.
+type shading int
+
+const (
+	shadeUnicode shading = iota // Unicode block elements of increasing density
+	shadeASCII                  // ASCII characters of increasing density
+	shadeANSI                   // ANSI background colors of increasing intensity
+)
+
+// parseShading returns the shading named by s, which may be "unicode",
+// "ascii", or "ansi". The name "auto" selects ANSI colors when colorful is
+// true, and ASCII otherwise.
+func parseShading(s string, colorful bool) (shading, error) {
+	switch s {
+	case "auto":
+		if colorful {
+			return shadeANSI, nil
+		}
+		return shadeASCII, nil
+	case "unicode":
+		return shadeUnicode, nil
+	case "ascii":
+		return shadeASCII, nil
+	case "ansi":
+		return shadeANSI, nil
+	}
+	return 0, fmt.Errorf("cannot use shading other than auto, unicode, ascii, or ansi: %q", s)
+}
+
+// shadeLevels is the number of distinct shades, the first of which is
+// reserved for cells with no value.
+const shadeLevels = 5
+
+var (
+	unicodeShades = [shadeLevels]string{" ", "░", "▒", "▓", "█"}
+	asciiShades   = [shadeLevels]string{" ", ".", ":", "*", "#"}
+	ansiShades    = [shadeLevels]int{236, 22, 28, 34, 40} // 256 color palette, from dark gray through bright green
+)
+
+// cell returns a cell of the specified shade level that is width columns
+// wide.
+func (s shading) cell(level, width int) string {
+	switch s {
+	case shadeASCII:
+		return strings.Repeat(asciiShades[level], width)
+	case shadeANSI:
+		return fmt.Sprintf("\x1b[48;5;%dm%s\x1b[0m", ansiShades[level], strings.Repeat(" ", width))
+	default:
+		return strings.Repeat(unicodeShades[level], width)
+	}
+}
+
+// shade returns the shade level for the value scaled against max.
+func shade(value, max float64) int {
+	if value <= 0 || max <= 0 {
+		return 0
+	}
+	if value >= max {
+		return shadeLevels - 1
+	}
+	return 1 + int((shadeLevels-1)*value/max)
+}
+
+// legend returns a line describing the range of values represented by each
+// shade level when scaled against max.
+func legend(s shading, max float64) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Legend: [%s] 0", s.cell(0, 1))
+	for i := 1; i < shadeLevels; i++ {
+		fmt.Fprintf(&sb, "  [%s] <= %.3g", s.cell(i, 1), max*float64(i)/float64(shadeLevels-1))
+	}
+	return sb.String()
+}
+
+// grid is a matrix of values displayed as shaded cells, with a label for each
+// row and each column.
+type grid struct {
+	rowLabels []string
+	colLabels []string    // labels wider than a cell extend over the cells that follow, and may be empty
+	cells     [][]float64 // cells[row][col]
+	cellWidth int         // number of columns each cell occupies
+	gap       int         // number of columns between cells
+}
+
+// newGrid returns a grid with a zero value cell for every row and column
+// label, and with cells wide enough to display each column label.
+func newGrid(rowLabels, colLabels []string) *grid {
+	cells := make([][]float64, len(rowLabels))
+	for i := range cells {
+		cells[i] = make([]float64, len(colLabels))
+	}
+	cellWidth := 1
+	for _, l := range colLabels {
+		if cellWidth < len(l) {
+			cellWidth = len(l)
+		}
+	}
+	return &grid{rowLabels: rowLabels, colLabels: colLabels, cells: cells, cellWidth: cellWidth, gap: 1}
+}
+
+// max returns the largest value of any cell in the grid.
+func (g *grid) max() float64 {
+	var max float64
+	for _, row := range g.cells {
+		for _, v := range row {
+			if max < v {
+				max = v
+			}
+		}
+	}
+	return max
+}
+
+// Print displays the grid as shaded cells followed by a legend describing the
+// value each shade represents.
+func (g *grid) Print(s shading) error {
+	max := g.max()
+	if err := g.print(s, max); err != nil {
+		return err
+	}
+	_, err := fmt.Println(legend(s, max))
+	return err
+}
+
+// print displays the column labels and the rows of shaded cells, each cell
+// scaled against max.
+func (g *grid) print(s shading, max float64) error {
+	labelWidth := 0
+	for _, l := range g.rowLabels {
+		if labelWidth < len(l) {
+			labelWidth = len(l)
+		}
+	}
+	gap := strings.Repeat(" ", g.gap)
+
+	// Right align each column label over its cell, unless it is wider than
+	// the cell, in which case it starts at its cell and extends to the right.
+	// Labels which would abut or overlap the previous label are skipped.
+	var sb strings.Builder
+	sb.WriteString(strings.Repeat(" ", labelWidth))
+	for i, l := range g.colLabels {
+		position := labelWidth + 1 + i*(g.cellWidth+g.gap)
+		if l == "" || position <= sb.Len() {
+			continue
+		}
+		if len(l) < g.cellWidth {
+			position += g.cellWidth - len(l)
+		}
+		sb.WriteString(strings.Repeat(" ", position-sb.Len()))
+		sb.WriteString(l)
+	}
+	if _, err := fmt.Println(sb.String()); err != nil {
+		return err
+	}
+
+	for i, row := range g.cells {
+		sb.Reset()
+		fmt.Fprintf(&sb, "%-*s", labelWidth, g.rowLabels[i])
+		for j, v := range row {
+			if j == 0 {
+				sb.WriteByte(' ')
+			} else {
+				sb.WriteString(gap)
+			}
+			sb.WriteString(s.cell(shade(v, max), g.cellWidth))
+		}
+		if _, err := fmt.Println(sb.String()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// rowTotal returns the sum of the cells of row i.
+func (g *grid) rowTotal(i int) float64 {
+	var total float64
+	for _, v := range g.cells[i] {
+		total += v
+	}
+	return total
+}
+
+// gridRows sorts the rows of a grid by their totals.
+type gridRows struct{ *grid }
+
+func (g gridRows) Len() int           { return len(g.cells) }
+func (g gridRows) Less(i, j int) bool { return g.rowTotal(i) < g.rowTotal(j) }
+func (g gridRows) Swap(i, j int) {
+	g.rowLabels[i], g.rowLabels[j] = g.rowLabels[j], g.rowLabels[i]
+	g.cells[i], g.cells[j] = g.cells[j], g.cells[i]
+}
+
+// SortAscending orders rows by increasing total, preserving the order of rows
+// with equal totals.
+func (g *grid) SortAscending() { sort.Stable(gridRows{g}) }
+
+// SortDescending orders rows by decreasing total, preserving the order of rows
+// with equal totals.
+func (g *grid) SortDescending() { sort.Stable(sort.Reverse(gridRows{g})) }
+
+var (
+	segmentGlyphs = []string{"#", "=", "+", "%", "@", "o", "~", ":", "x", "-"}
+	segmentColors = []int{33, 208, 40, 160, 135, 220, 44, 244, 199, 94} // 256 color palette of readily distinguished hues
+)
+
+// segment returns a bar segment of the specified index that is width columns
+// wide. ANSI shading draws each segment in its own color, while other shading
+// styles draw each segment with its own character. When there are more
+// segments than distinct glyphs or colors, they are reused.
+func (s shading) segment(index, width int) string {
+	if s == shadeANSI {
+		return fmt.Sprintf("\x1b[48;5;%dm%s\x1b[0m", segmentColors[index%len(segmentColors)], strings.Repeat(" ", width))
+	}
+	return strings.Repeat(segmentGlyphs[index%len(segmentGlyphs)], width)
+}
+
+// segmentLegend returns a line naming the label each bar segment represents.
+func segmentLegend(s shading, labels []string) string {
+	var sb strings.Builder
+	sb.WriteString("Legend:")
+	for i, l := range labels {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		fmt.Fprintf(&sb, " [%s] %s", s.segment(i, 1), l)
+	}
+	return sb.String()
+}
+
+// PrintStacked displays a row for each row of the grid with its label, its
+// total, and a bar scaled to fit within width columns, split into a segment
+// for each column of the grid. A legend follows, naming the column each
+// segment represents.
+func (g *grid) PrintStacked(width int, s shading) error {
+	if len(g.cells) == 0 {
+		return nil
+	}
+
+	totals := make([]string, len(g.cells))
+	keyWidth, countWidth := len("Key"), len("Count")
+	var barMax float64
+	for i, l := range g.rowLabels {
+		if keyWidth < len(l) {
+			keyWidth = len(l)
+		}
+		total := g.rowTotal(i)
+		totals[i] = strconv.FormatFloat(total, 'f', -1, 64)
+		if countWidth < len(totals[i]) {
+			countWidth = len(totals[i])
+		}
+		if barMax < total {
+			barMax = total
+		}
+	}
+
+	used := keyWidth + countWidth + 3 // space after key, space after count, plus 1 to keep from final column
+	adjustedWidth := width - used
+	if adjustedWidth < 1 {
+		return fmt.Errorf("cannot print with fewer than %d columns", used+1)
+	}
+
+	fmt.Printf("%-*s %*s (~%.3g per column)\n", keyWidth, "Key", countWidth, "Count", barMax/float64(adjustedWidth))
+	var sb strings.Builder
+	for i, row := range g.cells {
+		sb.Reset()
+		fmt.Fprintf(&sb, "%-*s %*s ", keyWidth, g.rowLabels[i], countWidth, totals[i])
+		if barMax > 0 {
+			// Each segment ends where the running total ends, so rounding
+			// does not accumulate across segments.
+			var sum float64
+			var previous int
+			for j, v := range row {
+				sum += v
+				end := int(float64(adjustedWidth) * sum / barMax)
+				if end > previous {
+					sb.WriteString(s.segment(j, end-previous))
+					previous = end
+				}
+			}
+		}
+		if _, err := fmt.Println(sb.String()); err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Println(segmentLegend(s, g.colLabels))
+	return err
+}
+
+// Table returns a table with a row for each row of the grid, having a column
+// for each column of the grid followed by their total, which drives the bar.
+func (g *grid) Table() *table {
+	t := newTable(append(append([]string{"Key"}, g.colLabels...), "Total")...)
+	for i, row := range g.cells {
+		cells := make([]string, 0, len(row)+2)
+		cells = append(cells, g.rowLabels[i])
+		for _, v := range row {
+			cells = append(cells, formatCount(v))
+		}
+		total := g.rowTotal(i)
+		t.Append(total, append(cells, formatCount(total))...)
+	}
+	return t
+}
+
+// PrintGrouped displays the same columns as the grid's table, but rather than
+// a single bar for the total of each row, displays a bar for each column of
+// the row on successive lines, each drawn as a distinct segment and scaled
+// against the largest cell of the grid. A legend follows, naming the column
+// each bar represents.
+func (g *grid) PrintGrouped(width int, s shading) error {
+	t := g.Table()
+	if len(t.rows) == 0 {
+		return nil
+	}
+
+	widths := t.widths()
+	used := 1 // plus 1 to keep from final column
+	for _, w := range widths {
+		used += w + 1 // column plus the space that follows it
+	}
+	adjustedWidth := width - used
+	if adjustedWidth < 1 {
+		return fmt.Errorf("cannot print with fewer than %d columns", used+1)
+	}
+
+	max := g.max()
+	fmt.Printf("%s(~%.3g per column)\n", t.format(widths, t.headers), max/float64(adjustedWidth))
+	blank := strings.Repeat(" ", used-1)
+	for i, row := range g.cells {
+		for j, v := range row {
+			prefix := blank
+			if j == 0 {
+				prefix = t.format(widths, t.rows[i])
+			}
+			var w int
+			if max > 0 {
+				w = int(float64(adjustedWidth) * v / max)
+			}
+			line := prefix
+			if w > 0 {
+				line += s.segment(j, w)
+			}
+			if _, err := fmt.Println(line); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err := fmt.Println(segmentLegend(s, g.colLabels))
+	return err
+}
+
+// PrintValues displays the grid as aligned columns of cell values formatted by
+// format, with a header row of column labels.
+func (g *grid) PrintValues(format func(float64) string) error {
+	labelWidth := 0
+	for _, l := range g.rowLabels {
+		if labelWidth < len(l) {
+			labelWidth = len(l)
+		}
+	}
+
+	formatted := make([][]string, len(g.cells))
+	widths := make([]int, len(g.colLabels))
+	for j, l := range g.colLabels {
+		widths[j] = len(l)
+	}
+	for i, row := range g.cells {
+		formatted[i] = make([]string, len(row))
+		for j, v := range row {
+			formatted[i][j] = format(v)
+			if widths[j] < len(formatted[i][j]) {
+				widths[j] = len(formatted[i][j])
+			}
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(strings.Repeat(" ", labelWidth))
+	for j, l := range g.colLabels {
+		fmt.Fprintf(&sb, " %*s", widths[j], l)
+	}
+	if _, err := fmt.Println(sb.String()); err != nil {
+		return err
+	}
+
+	for i, row := range formatted {
+		sb.Reset()
+		fmt.Fprintf(&sb, "%-*s", labelWidth, g.rowLabels[i])
+		for j, cell := range row {
+			fmt.Fprintf(&sb, " %*s", widths[j], cell)
+		}
+		if _, err := fmt.Println(sb.String()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PrintRaw displays the grid as tab separated values, with a header row of
+// column labels, and each subsequent row prefixed by its row label.
+func (g *grid) PrintRaw() error {
+	var sb strings.Builder
+	for _, l := range g.colLabels {
+		sb.WriteByte('\t')
+		sb.WriteString(l)
+	}
+	if _, err := fmt.Println(sb.String()); err != nil {
+		return err
+	}
+	for i, row := range g.cells {
+		sb.Reset()
+		sb.WriteString(g.rowLabels[i])
+		for _, v := range row {
+			sb.WriteByte('\t')
+			sb.WriteString(strconv.FormatFloat(v, 'f', -1, 64))
+		}
+		if _, err := fmt.Println(sb.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestTableSortDescending(t *testing.T) {
+	tab := newTable("Key", "Count")
+	tab.Append(1, "a", "1")
+	tab.Append(3, "b", "3")
+	tab.Append(1, "c", "1")
+
+	tab.SortDescending()
+
+	if got, want := tab.rows[0][0], "b"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	// stable for equal bars
+	if got, want := tab.rows[1][0], "a"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := tab.rows[2][0], "c"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestTableTop(t *testing.T) {
+	tab := newTable("Key", "Count")
+	tab.Append(1, "a", "1")
+	tab.Append(3, "b", "3")
+	tab.Append(2, "c", "2")
+	tab.Append(2, "d", "2")
+
+	tab.Top(2)
+
+	if got, want := len(tab.rows), 2; got != want {
+		t.Fatalf("GOT: %v; WANT: %v", got, want)
+	}
+	// original order, and first of equal bars
+	if got, want := tab.rows[0][0], "b"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := tab.rows[1][0], "c"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestTablePrintRaw(t *testing.T) {
+	tab := newTable("Key", "Count")
+	tab.Append(5, "/a", "5")
+	tab.Append(12, "/b", "12")
+
+	// Counts are padded to the widest count rather than to the header.
+	if got, want := captureStdout(t, tab.PrintRaw), " 5 /a\n12 /b\n"; got != want {
+		t.Errorf("GOT: %q; WANT: %q", got, want)
+	}
+}
+
+func TestParseShading(t *testing.T) {
+	s, err := parseShading("auto", false)
+	if err != nil {
+		t.Fatalf("GOT: %v; WANT: %v", err, nil)
+	}
+	if got, want := s, shadeASCII; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+
+	s, err = parseShading("auto", true)
+	if err != nil {
+		t.Fatalf("GOT: %v; WANT: %v", err, nil)
+	}
+	if got, want := s, shadeANSI; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+
+	if _, err = parseShading("neon", true); err == nil {
+		t.Errorf("GOT: %v; WANT: %v", err, "non-nil")
+	}
+}
+
+func TestShade(t *testing.T) {
+	cases := []struct {
+		value, max float64
+		level      int
+	}{
+		{0, 10, 0},
+		{1, 10, 1},
+		{2.5, 10, 2},
+		{7.5, 10, 4},
+		{10, 10, 4},
+		{1, 0, 0},
+	}
+	for _, c := range cases {
+		if got, want := shade(c.value, c.max), c.level; got != want {
+			t.Errorf("%v/%v: GOT: %v; WANT: %v", c.value, c.max, got, want)
+		}
+	}
+}
+
+func TestGridSortDescending(t *testing.T) {
+	g := newGrid([]string{"a", "b", "c"}, []string{"x", "y"})
+	g.cells[0] = []float64{1, 0}
+	g.cells[1] = []float64{1, 2}
+	g.cells[2] = []float64{0, 1}
+
+	g.SortDescending()
+
+	if got, want := g.rowLabels[0], "b"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := g.cells[0][1], 2.0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	// stable for equal totals
+	if got, want := g.rowLabels[1], "a"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := g.rowLabels[2], "c"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestShadingSegment(t *testing.T) {
+	if got, want := shadeASCII.segment(1, 3), "==="; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	// glyphs are reused once exhausted
+	if got, want := shadeUnicode.segment(len(segmentGlyphs), 1), "#"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := shadeANSI.segment(0, 1), "\x1b[48;5;33m \x1b[0m"; got != want {
+		t.Errorf("GOT: %q; WANT: %q", got, want)
+	}
+}
+
+func TestGridTable(t *testing.T) {
+	g := newGrid([]string{"a", "b"}, []string{"x.log", "y.log"})
+	g.cells[0] = []float64{1, 2}
+	g.cells[1] = []float64{0, 4}
+
+	tab := g.Table()
+
+	if got, want := len(tab.headers), 4; got != want {
+		t.Fatalf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := tab.headers[3], "Total"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := tab.rows[0][3], "3"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := tab.bars[1], 4.0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestSegmentLegend(t *testing.T) {
+	if got, want := segmentLegend(shadeASCII, []string{"200", "404"}), "Legend: [#] 200  [=] 404"; got != want {
+		t.Errorf("GOT: %q; WANT: %q", got, want)
+	}
+}
+
+func TestTableSortMagnitudeDescending(t *testing.T) {
+	tab := newTable("Key", "Change")
+	tab.Append(1, "a", "+1")
+	tab.Append(-3, "b", "-3")
+	tab.Append(-1, "c", "-1")
+	tab.Append(2, "d", "+2")
+
+	tab.SortMagnitudeDescending()
+
+	for i, want := range []string{"b", "d", "a", "c"} {
+		if got := tab.rows[i][0]; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	}
+}
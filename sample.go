@@ -0,0 +1,104 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Sampler selects a fraction of input lines, either each line independently
+// at random, or by the hash of each line's key, so that every line of a key
+// is kept or dropped together.
+type Sampler struct {
+	rate      float64
+	byKey     bool
+	threshold uint64 // keys whose hash is less than this are kept
+	rng       *rand.Rand
+	seen      int // number of lines offered
+	kept      int // number of lines kept
+}
+
+// NewSampler returns a Sampler which keeps lines with probability rate. When
+// byKey is true, it keeps the lines of a key when the hash of the key falls
+// within the first rate of all hashes, otherwise it keeps each line at random
+// using a generator seeded by seed.
+func NewSampler(rate float64, byKey bool, seed int64) *Sampler {
+	s := &Sampler{rate: rate, byKey: byKey, rng: rand.New(rand.NewSource(seed))}
+	if rate >= 1 {
+		s.threshold = math.MaxUint64
+	} else {
+		s.threshold = uint64(rate * math.MaxUint64)
+	}
+	return s
+}
+
+// Keep returns true when the line having the key should be counted. The key
+// is ignored unless sampling by key.
+func (s *Sampler) Keep(key string) bool {
+	s.seen++
+	var keep bool
+	if s.byKey {
+		keep = s.rate >= 1 || mixedHash(key) < s.threshold
+	} else {
+		keep = s.rng.Float64() < s.rate
+	}
+	if keep {
+		s.kept++
+	}
+	return keep
+}
+
+// Seen returns the number of lines offered to Keep.
+func (s *Sampler) Seen() int { return s.seen }
+
+// Kept returns the number of lines Keep kept.
+func (s *Sampler) Kept() int { return s.kept }
+
+type reservoirLine struct {
+	index int // position of the line among all lines offered
+	line  string
+}
+
+// Reservoir keeps a uniform random sample of at most size lines of a stream
+// of unknown length, using Vitter's Algorithm R.
+type Reservoir struct {
+	size  int
+	lines []reservoirLine
+	rng   *rand.Rand
+	seen  int // number of lines offered
+}
+
+// NewReservoir returns an empty Reservoir which keeps at most size lines,
+// choosing them using a generator seeded by seed.
+func NewReservoir(size int, seed int64) *Reservoir {
+	return &Reservoir{size: size, lines: make([]reservoirLine, 0, size), rng: rand.New(rand.NewSource(seed))}
+}
+
+// Add offers the line to the reservoir. The first size lines are kept, after
+// which each line replaces a random kept line with probability size divided
+// by the number of lines offered.
+func (r *Reservoir) Add(line string) {
+	r.seen++
+	if len(r.lines) < r.size {
+		r.lines = append(r.lines, reservoirLine{index: r.seen, line: line})
+		return
+	}
+	if i := r.rng.Intn(r.seen); i < r.size {
+		r.lines[i] = reservoirLine{index: r.seen, line: line}
+	}
+}
+
+// Each invokes callback with each kept line, in the order they were offered,
+// so keys are still displayed in the order they were first seen.
+func (r *Reservoir) Each(callback func(line string)) {
+	sort.Slice(r.lines, func(i, j int) bool { return r.lines[i].index < r.lines[j].index })
+	for _, rl := range r.lines {
+		callback(rl.line)
+	}
+}
+
+// Seen returns the number of lines offered to Add.
+func (r *Reservoir) Seen() int { return r.seen }
+
+// Kept returns the number of lines kept.
+func (r *Reservoir) Kept() int { return len(r.lines) }
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestSamplerByKey(t *testing.T) {
+	s := NewSampler(0.5, true, 1)
+	kept := make(map[string]bool)
+
+	for i := 0; i < 1000; i++ {
+		key := strconv.Itoa(i % 100)
+		keep := s.Keep(key)
+		if previous, ok := kept[key]; ok && previous != keep {
+			t.Fatalf("%s: GOT: %v; WANT: %v", key, keep, previous)
+		}
+		kept[key] = keep
+	}
+
+	if got, want := s.Seen(), 1000; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got := s.Kept(); got < 300 || got > 700 {
+		t.Errorf("GOT: %v; WANT: %v", got, 500)
+	}
+}
+
+func TestSamplerBernoulli(t *testing.T) {
+	s := NewSampler(0.1, false, 1)
+	for i := 0; i < 10000; i++ {
+		s.Keep("")
+	}
+
+	if got := s.Kept(); got < 850 || got > 1150 {
+		t.Errorf("GOT: %v; WANT: %v", got, 1000)
+	}
+}
+
+func TestSamplerKeepsAll(t *testing.T) {
+	for _, byKey := range []bool{false, true} {
+		s := NewSampler(1, byKey, 1)
+		for i := 0; i < 100; i++ {
+			s.Keep(strconv.Itoa(i))
+		}
+
+		if got, want := s.Kept(), 100; got != want {
+			t.Errorf("by key %v: GOT: %v; WANT: %v", byKey, got, want)
+		}
+	}
+}
+
+func TestReservoir(t *testing.T) {
+	r := NewReservoir(10, 1)
+	for i := 0; i < 1000; i++ {
+		r.Add(strconv.Itoa(i))
+	}
+
+	var lines []int
+	r.Each(func(line string) {
+		i, _ := strconv.Atoi(line)
+		lines = append(lines, i)
+	})
+
+	if got, want := r.Seen(), 1000; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := len(lines), 10; got != want {
+		t.Fatalf("GOT: %v; WANT: %v", got, want)
+	}
+	// in the order offered
+	for i := 1; i < len(lines); i++ {
+		if lines[i-1] >= lines[i] {
+			t.Errorf("GOT: %v; WANT: increasing", lines)
+			break
+		}
+	}
+	// later lines replace some of the first lines
+	if got := lines[len(lines)-1]; got < 10 {
+		t.Errorf("GOT: %v; WANT: >= %v", got, 10)
+	}
+}
+
+func TestReservoirFewerLines(t *testing.T) {
+	r := NewReservoir(10, 1)
+	r.Add("a")
+	r.Add("b")
+
+	var lines []string
+	r.Each(func(line string) { lines = append(lines, line) })
+
+	if got, want := len(lines), 2; got != want {
+		t.Fatalf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := lines[0], "a"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestMarkSampledRawQuiet(t *testing.T) {
+	raw, quiet := *optRaw, *optQuiet
+	defer func() { *optRaw, *optQuiet = raw, quiet }()
+	*optRaw, *optQuiet = true, true
+
+	var stdout string
+	stderr := captureStderr(t, func() error {
+		stdout = captureStdout(t, func() error {
+			markSampled("sampled 10 of 100 lines")
+			return nil
+		})
+		return nil
+	})
+
+	if got, want := stdout, ""; got != want {
+		t.Errorf("GOT: %q; WANT: %q", got, want)
+	}
+	if got, want := stderr, ProgramName+": sampled 10 of 100 lines\n"; got != want {
+		t.Errorf("GOT: %q; WANT: %q", got, want)
+	}
+}
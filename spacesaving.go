@@ -0,0 +1,111 @@
+package main
+
+import (
+	"container/heap"
+	"sort"
+	"strconv"
+)
+
+// ssEntry is a key monitored by a SpaceSaving summary.
+type ssEntry struct {
+	key   string
+	count int // estimated count, which never underestimates the true count
+	error int // amount by which count may overestimate the true count
+	index int // position in the heap
+}
+
+// ssHeap is a min heap of monitored keys ordered by their estimated counts.
+type ssHeap []*ssEntry
+
+func (h ssHeap) Len() int           { return len(h) }
+func (h ssHeap) Less(i, j int) bool { return h[i].count < h[j].count }
+func (h ssHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+func (h *ssHeap) Push(x interface{}) {
+	e := x.(*ssEntry)
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+func (h *ssHeap) Pop() interface{} {
+	old := *h
+	e := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return e
+}
+
+// SpaceSaving estimates the counts of the most frequent keys of a stream in
+// fixed memory, using the Space-Saving algorithm of Metwally, Agrawal, and
+// El Abbadi. It monitors at most capacity keys. When a key which is not
+// monitored arrives while all slots are in use, it replaces the monitored key
+// having the smallest count, inheriting that count as its possible error.
+// Every key whose true count exceeds the total count divided by capacity is
+// guaranteed to be monitored.
+type SpaceSaving struct {
+	capacity int
+	entries  ssHeap
+	indexes  map[string]*ssEntry
+	total    int // sum of counts of all keys added
+}
+
+// NewSpaceSaving returns an empty SpaceSaving which monitors at most capacity
+// keys.
+func NewSpaceSaving(capacity int) *SpaceSaving {
+	return &SpaceSaving{capacity: capacity, indexes: make(map[string]*ssEntry, capacity)}
+}
+
+// Add counts the key once.
+func (ss *SpaceSaving) Add(key string) {
+	ss.AddCount(key, 1)
+}
+
+// AddCount counts the key count times.
+func (ss *SpaceSaving) AddCount(key string, count int) {
+	ss.total += count
+	if e, ok := ss.indexes[key]; ok {
+		e.count += count
+		heap.Fix(&ss.entries, e.index)
+		return
+	}
+	if len(ss.entries) < ss.capacity {
+		e := &ssEntry{key: key, count: count}
+		ss.indexes[key] = e
+		heap.Push(&ss.entries, e)
+		return
+	}
+	// Replace the key with the smallest count.
+	e := ss.entries[0]
+	delete(ss.indexes, e.key)
+	e.key, e.error, e.count = key, e.count, e.count+count
+	ss.indexes[key] = e
+	heap.Fix(&ss.entries, 0)
+}
+
+// Total returns the sum of the counts of all keys added.
+func (ss *SpaceSaving) Total() int { return ss.total }
+
+// Table returns a table with a row for each of the k monitored keys having
+// the largest estimated counts, in decreasing order of estimated count. Each
+// row shows the estimated count, which drives the bar, along with the amount
+// by which it may overestimate the true count.
+func (ss *SpaceSaving) Table(k int) *table {
+	entries := make([]*ssEntry, len(ss.entries))
+	copy(entries, ss.entries)
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].count != entries[j].count {
+			return entries[i].count > entries[j].count
+		}
+		return entries[i].error < entries[j].error
+	})
+	if k < len(entries) {
+		entries = entries[:k]
+	}
+
+	t := newTable("Key", "Count", "Error")
+	for _, e := range entries {
+		t.Append(float64(e.count), e.key, strconv.Itoa(e.count), strconv.Itoa(e.error))
+	}
+	return t
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestSpaceSavingExactWithinCapacity(t *testing.T) {
+	ss := NewSpaceSaving(3)
+	ss.Add("a")
+	ss.Add("b")
+	ss.AddCount("a", 2)
+	ss.Add("c")
+
+	tab := ss.Table(2)
+
+	if got, want := len(tab.rows), 2; got != want {
+		t.Fatalf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := tab.rows[0][0], "a"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := tab.rows[0][1], "3"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := tab.rows[0][2], "0"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestSpaceSavingReplacesSmallest(t *testing.T) {
+	ss := NewSpaceSaving(2)
+	ss.AddCount("a", 5)
+	ss.AddCount("b", 2)
+	ss.Add("c") // replaces b, inheriting its count as error
+
+	tab := ss.Table(2)
+
+	if got, want := tab.rows[1][0], "c"; got != want {
+		t.Fatalf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := tab.rows[1][1], "3"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := tab.rows[1][2], "2"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestSpaceSavingHeavyHitters(t *testing.T) {
+	ss := NewSpaceSaving(50)
+	exact := make(map[string]int)
+
+	// Three heavy keys among a long tail of keys seen once each.
+	for i := 0; i < 1000; i++ {
+		var key string
+		switch {
+		case i%4 == 0:
+			key = "heavy-a"
+		case i%6 == 0:
+			key = "heavy-b"
+		case i%10 == 0:
+			key = "heavy-c"
+		default:
+			key = strconv.Itoa(i)
+		}
+		ss.Add(key)
+		exact[key]++
+	}
+
+	tab := ss.Table(3)
+
+	for i, want := range []string{"heavy-a", "heavy-b", "heavy-c"} {
+		if got := tab.rows[i][0]; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		count, _ := strconv.Atoi(tab.rows[i][1])
+		overestimate, _ := strconv.Atoi(tab.rows[i][2])
+		if actual := exact[want]; count < actual || count-overestimate > actual {
+			t.Errorf("%s: %d true count outside of estimate %d with error %d", want, actual, count, overestimate)
+		}
+		if overestimate > ss.Total()/50 {
+			t.Errorf("%s: error %d exceeds bound %d", want, overestimate, ss.Total()/50)
+		}
+	}
+}
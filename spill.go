@@ -0,0 +1,426 @@
+package main
+
+import (
+	"bufio"
+	"container/heap"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// spillOverhead approximates the bytes of memory used by each key held in
+// memory, beyond the bytes of the key itself: its map entry, its record, and
+// the headers of its strings.
+const spillOverhead = 96
+
+// spillFanIn is the largest number of runs merged at once, which bounds the
+// number of files open at once. When there are this many runs, they are
+// merged into a single run before spilling more.
+const spillFanIn = 64
+
+// spillRecord is the count of a key, along with the position among all keys
+// added at which the key was first seen.
+type spillRecord struct {
+	key   string
+	count int
+	first int
+}
+
+// spillByKey orders records by key.
+func spillByKey(a, b *spillRecord) bool { return a.key < b.key }
+
+// spillByFirst orders records by the position at which each key was first
+// seen.
+func spillByFirst(a, b *spillRecord) bool { return a.first < b.first }
+
+// spillByCountAscending orders records by increasing count, then by the
+// position at which each key was first seen.
+func spillByCountAscending(a, b *spillRecord) bool {
+	if a.count != b.count {
+		return a.count < b.count
+	}
+	return a.first < b.first
+}
+
+// spillByCountDescending orders records by decreasing count, then by the
+// position at which each key was first seen.
+func spillByCountDescending(a, b *spillRecord) bool {
+	if a.count != b.count {
+		return a.count > b.count
+	}
+	return a.first < b.first
+}
+
+// SpillCounter counts keys exactly using a bounded amount of memory. When the
+// keys held in memory exceed its budget, it sorts them by key and writes them
+// to a temporary file, called a run, then continues counting afresh. Runs are
+// merged when the counts are read back, so the count of each key is exact no
+// matter how many distinct keys there are, and keys are still displayed in
+// the order they were first seen.
+type SpillCounter struct {
+	budget  int // bytes of memory to use for keys before spilling them
+	used    int // approximate bytes of memory used by keys held in memory
+	dir     string
+	records map[string]*spillRecord
+	runs    []string // pathnames of runs sorted by key
+	added   int      // number of times any key was added
+	spills  int      // number of times keys were spilled
+	err     error    // first error spilling, after which keys are ignored
+}
+
+// NewSpillCounter returns an empty SpillCounter which uses about budget bytes
+// of memory for keys, spilling the rest to a new temporary directory, which
+// is removed by Close.
+func NewSpillCounter(budget int) (*SpillCounter, error) {
+	dir, err := ioutil.TempDir("", "histogram")
+	if err != nil {
+		return nil, fmt.Errorf("cannot spill counts: %s", err)
+	}
+	return &SpillCounter{budget: budget, dir: dir, records: make(map[string]*spillRecord)}, nil
+}
+
+// Close removes every run written by sc.
+func (sc *SpillCounter) Close() error {
+	return os.RemoveAll(sc.dir)
+}
+
+// Spills returns the number of times keys were spilled to runs.
+func (sc *SpillCounter) Spills() int { return sc.spills }
+
+// Add counts the key once.
+func (sc *SpillCounter) Add(key string) {
+	sc.AddCount(key, 1)
+}
+
+// AddCount counts the key count times, spilling the keys held in memory when
+// they exceed the budget.
+func (sc *SpillCounter) AddCount(key string, count int) {
+	if sc.err != nil {
+		return
+	}
+	sc.added++
+	if r, ok := sc.records[key]; ok {
+		r.count += count
+		return
+	}
+	sc.records[key] = &spillRecord{key: key, count: count, first: sc.added}
+	sc.used += len(key) + spillOverhead
+	if sc.used > sc.budget {
+		sc.err = sc.spill()
+	}
+}
+
+// spill writes the keys held in memory to a new run, then forgets them.
+func (sc *SpillCounter) spill() error {
+	records := make([]*spillRecord, 0, len(sc.records))
+	for _, r := range sc.records {
+		records = append(records, r)
+	}
+	runs, err := appendRun(sc.dir, sc.runs, records, spillByKey)
+	if err != nil {
+		return err
+	}
+	sc.runs = runs
+	sc.spills++
+	sc.records = make(map[string]*spillRecord)
+	sc.used = 0
+	return nil
+}
+
+// fold invokes callback with each distinct key and its total count, in order
+// of key.
+func (sc *SpillCounter) fold(callback func(r *spillRecord) error) error {
+	if len(sc.runs) > 0 && len(sc.records) > 0 {
+		if err := sc.spill(); err != nil {
+			return err
+		}
+	}
+	if len(sc.runs) == 0 {
+		records := make([]*spillRecord, 0, len(sc.records))
+		for _, r := range sc.records {
+			records = append(records, r)
+		}
+		sort.Slice(records, func(i, j int) bool { return spillByKey(records[i], records[j]) })
+		for _, r := range records {
+			if err := callback(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// Runs are sorted by key, so every record of a key is merged adjacent to
+	// the others.
+	var pending *spillRecord
+	err := mergeRuns(sc.runs, spillByKey, func(r *spillRecord) error {
+		if pending != nil && pending.key == r.key {
+			pending.count += r.count
+			if r.first < pending.first {
+				pending.first = r.first
+			}
+			return nil
+		}
+		if pending != nil {
+			if err := callback(pending); err != nil {
+				return err
+			}
+		}
+		pending = r
+		return nil
+	})
+	if err == nil && pending != nil {
+		err = callback(pending)
+	}
+	return err
+}
+
+// Sort invokes folded with each distinct key and its total count in an
+// unspecified order, then invokes sorted with each of them again in the order
+// given by less. Keys are spilled to runs as required to remain within the
+// budget of memory.
+func (sc *SpillCounter) Sort(less func(a, b *spillRecord) bool, folded func(r *spillRecord), sorted func(r *spillRecord) error) error {
+	if sc.err != nil {
+		return sc.err
+	}
+
+	var records []*spillRecord
+	var runs []string
+	var used int
+	err := sc.fold(func(r *spillRecord) error {
+		folded(r)
+		records = append(records, r)
+		used += len(r.key) + spillOverhead
+		if used > sc.budget {
+			var err error
+			if runs, err = appendRun(sc.dir, runs, records, less); err != nil {
+				return err
+			}
+			records, used = nil, 0
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(runs) == 0 {
+		sort.Slice(records, func(i, j int) bool { return less(records[i], records[j]) })
+		for _, r := range records {
+			if err = sorted(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if len(records) > 0 {
+		if runs, err = appendRun(sc.dir, runs, records, less); err != nil {
+			return err
+		}
+	}
+	return mergeRuns(runs, less, sorted)
+}
+
+// appendRun sorts the records by less, writes them to a new run in dir, and
+// returns runs with its pathname appended. When there are spillFanIn runs,
+// they are first merged into a single run.
+func appendRun(dir string, runs []string, records []*spillRecord, less func(a, b *spillRecord) bool) ([]string, error) {
+	if len(runs) == spillFanIn {
+		rw, err := newRunWriter(dir)
+		if err != nil {
+			return nil, err
+		}
+		err = mergeRuns(runs, less, rw.write)
+		if cerr := rw.close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, pathname := range runs {
+			_ = os.Remove(pathname)
+		}
+		runs = []string{rw.fh.Name()}
+	}
+
+	sort.Slice(records, func(i, j int) bool { return less(records[i], records[j]) })
+	rw, err := newRunWriter(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range records {
+		if err = rw.write(r); err != nil {
+			break
+		}
+	}
+	if cerr := rw.close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return append(runs, rw.fh.Name()), nil
+}
+
+// runWriter writes records to a new run. Each record is written on its own
+// line, as the position it was first seen, its count, and its key, separated
+// by tabs. Keys never contain newlines because they are selected from lines.
+type runWriter struct {
+	fh *os.File
+	bw *bufio.Writer
+}
+
+// newRunWriter returns a runWriter which writes to a new temporary file in
+// dir.
+func newRunWriter(dir string) (*runWriter, error) {
+	fh, err := ioutil.TempFile(dir, "run")
+	if err != nil {
+		return nil, fmt.Errorf("cannot spill counts: %s", err)
+	}
+	return &runWriter{fh: fh, bw: bufio.NewWriter(fh)}, nil
+}
+
+// write appends the record to the run.
+func (rw *runWriter) write(r *spillRecord) error {
+	if _, err := fmt.Fprintf(rw.bw, "%d\t%d\t%s\n", r.first, r.count, r.key); err != nil {
+		return fmt.Errorf("cannot spill counts: %s", err)
+	}
+	return nil
+}
+
+// close flushes and closes the run.
+func (rw *runWriter) close() error {
+	err := rw.bw.Flush()
+	if cerr := rw.fh.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("cannot spill counts: %s", err)
+	}
+	return nil
+}
+
+// runReader reads the records of a run in order.
+type runReader struct {
+	fh   *os.File
+	br   *bufio.Reader
+	head *spillRecord // next record of the run
+}
+
+// next reads the next record of the run into head, which is nil at the end of
+// the run.
+func (rr *runReader) next() error {
+	line, err := rr.br.ReadString('\n')
+	if err == io.EOF && line == "" {
+		rr.head = nil
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("cannot read spilled counts: %s", err)
+	}
+	fields := strings.SplitN(strings.TrimSuffix(line, "\n"), "\t", 3)
+	if len(fields) != 3 {
+		return fmt.Errorf("cannot read spilled counts: %s: malformed record", rr.fh.Name())
+	}
+	first, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return fmt.Errorf("cannot read spilled counts: %s: %s", rr.fh.Name(), err)
+	}
+	count, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return fmt.Errorf("cannot read spilled counts: %s: %s", rr.fh.Name(), err)
+	}
+	rr.head = &spillRecord{key: fields[2], count: count, first: first}
+	return nil
+}
+
+// runHeap is a min heap of runs ordered by their next records.
+type runHeap struct {
+	readers []*runReader
+	less    func(a, b *spillRecord) bool
+}
+
+func (h runHeap) Len() int            { return len(h.readers) }
+func (h runHeap) Less(i, j int) bool  { return h.less(h.readers[i].head, h.readers[j].head) }
+func (h runHeap) Swap(i, j int)       { h.readers[i], h.readers[j] = h.readers[j], h.readers[i] }
+func (h *runHeap) Push(x interface{}) { h.readers = append(h.readers, x.(*runReader)) }
+func (h *runHeap) Pop() interface{} {
+	old := h.readers
+	rr := old[len(old)-1]
+	h.readers = old[:len(old)-1]
+	return rr
+}
+
+// mergeRuns invokes callback with every record of the runs, each of which is
+// sorted by less, in the order given by less.
+func mergeRuns(pathnames []string, less func(a, b *spillRecord) bool, callback func(r *spillRecord) error) error {
+	h := &runHeap{less: less}
+	defer func() {
+		for _, rr := range h.readers {
+			_ = rr.fh.Close()
+		}
+	}()
+	for _, pathname := range pathnames {
+		fh, err := os.Open(pathname)
+		if err != nil {
+			return fmt.Errorf("cannot read spilled counts: %s", err)
+		}
+		rr := &runReader{fh: fh, br: bufio.NewReader(fh)}
+		if err = rr.next(); err != nil {
+			_ = fh.Close()
+			return err
+		}
+		if rr.head == nil {
+			_ = fh.Close()
+			continue
+		}
+		h.readers = append(h.readers, rr)
+	}
+	heap.Init(h)
+
+	for h.Len() > 0 {
+		rr := h.readers[0]
+		if err := callback(rr.head); err != nil {
+			return err
+		}
+		if err := rr.next(); err != nil {
+			return err
+		}
+		if rr.head == nil {
+			_ = rr.fh.Close()
+			heap.Pop(h)
+		} else {
+			heap.Fix(h, 0)
+		}
+	}
+	return nil
+}
+
+// parseSize returns the number of bytes described by s, which is an integer
+// optionally followed by a suffix of K, M, or G, for powers of 1024, and an
+// optional B.
+func parseSize(s string) (int, error) {
+	digits := strings.TrimSuffix(strings.ToUpper(s), "B")
+	multiplier := 1
+	if n := len(digits); n > 0 {
+		switch digits[n-1] {
+		case 'K':
+			multiplier = 1 << 10
+		case 'M':
+			multiplier = 1 << 20
+		case 'G':
+			multiplier = 1 << 30
+		}
+		if multiplier > 1 {
+			digits = digits[:n-1]
+		}
+	}
+	n, err := strconv.Atoi(digits)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("cannot parse size: %q", s)
+	}
+	return n * multiplier, nil
+}
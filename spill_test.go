@@ -0,0 +1,104 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"testing"
+)
+
+// spillKeys adds keys to both a SpillCounter and a Counter, in an order
+// which sees some keys again after they were spilled.
+func spillKeys(sc *SpillCounter, c *Counter) {
+	for i := 0; i < 500; i++ {
+		key := strconv.Itoa(i * 7 % 61)
+		sc.AddCount(key, i%3+1)
+		c.AddCount(key, i%3+1)
+	}
+}
+
+func TestSpillCounterMatchesCounter(t *testing.T) {
+	for name, c := range map[string]struct {
+		less func(a, b *spillRecord) bool
+		sort func(c *Counter)
+	}{
+		"first":      {spillByFirst, func(*Counter) {}},
+		"ascending":  {spillByCountAscending, (*Counter).SortAscending},
+		"descending": {spillByCountDescending, (*Counter).SortDescending},
+	} {
+		t.Run(name, func(t *testing.T) {
+			sc, err := NewSpillCounter(4 * spillOverhead)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer sc.Close()
+			counter := NewCounter()
+			spillKeys(sc, counter)
+			c.sort(counter)
+
+			var total int
+			var got []counterItem
+			err = sc.Sort(c.less, func(r *spillRecord) {
+				total += r.count
+			}, func(r *spillRecord) error {
+				got = append(got, counterItem{key: r.key, count: r.count})
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Enough spills to merge runs before reading them back.
+			if sc.Spills() <= spillFanIn {
+				t.Errorf("GOT: %v; WANT: > %v", sc.Spills(), spillFanIn)
+			}
+			if got, want := total, counter.Total(); got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+			if got, want := len(got), counter.Len(); got != want {
+				t.Fatalf("GOT: %v; WANT: %v", got, want)
+			}
+			for i, item := range counter.items {
+				if got[i] != *item {
+					t.Errorf("%d: GOT: %v; WANT: %v", i, got[i], *item)
+				}
+			}
+		})
+	}
+}
+
+func TestSpillCounterClose(t *testing.T) {
+	sc, err := NewSpillCounter(spillOverhead)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spillKeys(sc, NewCounter())
+	if err = sc.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = os.Stat(sc.dir); !os.IsNotExist(err) {
+		t.Errorf("GOT: %v; WANT: %v", err, "not exist")
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	for s, want := range map[string]int{
+		"100":  100,
+		"4k":   4 << 10,
+		"512M": 512 << 20,
+		"2GB":  2 << 30,
+	} {
+		got, err := parseSize(s)
+		if err != nil {
+			t.Errorf("%s: %s", s, err)
+		}
+		if got != want {
+			t.Errorf("%s: GOT: %v; WANT: %v", s, got, want)
+		}
+	}
+	for _, s := range []string{"", "M", "0", "-1K", "1X"} {
+		if _, err := parseSize(s); err == nil {
+			t.Errorf("%s: GOT: %v; WANT: %v", s, err, "error")
+		}
+	}
+}
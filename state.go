@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// stateVersion is the version of the format written by SaveState. LoadState
+// refuses to load states written in any other version.
+const stateVersion = 1
+
+// state is the on-disk representation of the count of each key.
+type state struct {
+	Version int               `json:"version"`
+	Kind    string            `json:"kind,omitempty"`    // empty for counts of keys, otherwise the kind of sketch
+	Options map[string]string `json:"options,omitempty"` // options used when counting the keys
+	Total   int               `json:"total"`             // sum of counts of all keys
+	Keys    []stateKey        `json:"keys"`              // in the order they were first seen
+}
+
+type stateKey struct {
+	Key   string `json:"key"`
+	Count int    `json:"count"`
+}
+
+// SaveState writes the count of each key of c, along with the options used to
+// count them, to the named file. The file is replaced atomically, so an
+// existing file is left intact when the state cannot be written.
+func SaveState(pathname string, c *Counter, options map[string]string) error {
+	s := state{Version: stateVersion, Options: options, Total: c.Total(), Keys: make([]stateKey, 0, c.Len())}
+	c.Each(func(key string, count int) {
+		s.Keys = append(s.Keys, stateKey{Key: key, Count: count})
+	})
+	return saveJSON(pathname, s)
+}
+
+// LoadState adds the count of each key read from the named file to c, and
+// returns the options used to count them.
+func LoadState(pathname string, c *Counter) (map[string]string, error) {
+	var s state
+	if err := loadJSON(pathname, &s); err != nil {
+		return nil, err
+	}
+	if s.Version != stateVersion {
+		return nil, fmt.Errorf("cannot load state: %s: unsupported version %d", pathname, s.Version)
+	}
+	if s.Kind != "" {
+		return nil, fmt.Errorf("cannot load state: %s: %s rather than counts of keys", pathname, s.Kind)
+	}
+	var total int
+	for _, k := range s.Keys {
+		total += k.Count
+	}
+	if total != s.Total {
+		return nil, fmt.Errorf("cannot load state: %s: counts sum to %d rather than total %d", pathname, total, s.Total)
+	}
+
+	for _, k := range s.Keys {
+		c.AddCount(k.Key, k.Count)
+	}
+	return s.Options, nil
+}
+
+// saveJSON atomically replaces the named file with the JSON encoding of v.
+func saveJSON(pathname string, v interface{}) error {
+	fh, err := ioutil.TempFile(filepath.Dir(pathname), filepath.Base(pathname)+".")
+	if err != nil {
+		return fmt.Errorf("cannot save state: %s", err)
+	}
+	err = json.NewEncoder(fh).Encode(v)
+	if cerr := fh.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(fh.Name(), pathname)
+	}
+	if err != nil {
+		_ = os.Remove(fh.Name())
+		return fmt.Errorf("cannot save state: %s", err)
+	}
+	return nil
+}
+
+// loadJSON decodes the JSON encoded contents of the named file into v.
+func loadJSON(pathname string, v interface{}) error {
+	fh, err := os.Open(pathname)
+	if err != nil {
+		return fmt.Errorf("cannot load state: %s", err)
+	}
+	defer fh.Close()
+	if err = json.NewDecoder(fh).Decode(v); err != nil {
+		return fmt.Errorf("cannot load state: %s: %s", pathname, err)
+	}
+	return nil
+}
+
+// statePathnames returns the pathnames named by the comma delimited list of
+// pathnames and glob patterns, in order. Patterns which match no files are
+// an error.
+func statePathnames(list string) ([]string, error) {
+	var pathnames []string
+	for _, pattern := range strings.Split(list, ",") {
+		if pattern == "" {
+			continue
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load state: %s", err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("cannot load state: no such file: %s", pattern)
+		}
+		pathnames = append(pathnames, matches...)
+	}
+	return pathnames, nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStateRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "histogram")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	pathname := filepath.Join(dir, "monday.hist")
+
+	c := NewCounter()
+	c.AddCount("b", 2)
+	c.AddCount("a", 1)
+	if err = SaveState(pathname, c, map[string]string{"field": "3"}); err != nil {
+		t.Fatal(err)
+	}
+
+	merged := NewCounter()
+	merged.AddCount("a", 4)
+	options, err := LoadState(pathname, merged)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := options["field"], "3"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := merged.Total(), 7; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := merged.items[0].count, 5; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := merged.items[1].key, "b"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestLoadStateRejects(t *testing.T) {
+	dir, err := ioutil.TempDir("", "histogram")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cases := map[string]string{
+		"version": `{"version":2,"total":0,"keys":[]}`,
+		"total":   `{"version":1,"total":3,"keys":[{"key":"a","count":2}]}`,
+		"syntax":  `{"version":1,`,
+	}
+	for name, contents := range cases {
+		pathname := filepath.Join(dir, name)
+		if err = ioutil.WriteFile(pathname, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = LoadState(pathname, NewCounter()); err == nil {
+			t.Errorf("%s GOT: %v; WANT: %v", name, err, "non-nil")
+		}
+	}
+}
+
+func TestStatePathnames(t *testing.T) {
+	dir, err := ioutil.TempDir("", "histogram")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"a.hist", "b.hist", "c.txt"} {
+		if err = ioutil.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pathnames, err := statePathnames(filepath.Join(dir, "*.hist") + "," + filepath.Join(dir, "c.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(pathnames), 3; got != want {
+		t.Fatalf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := filepath.Base(pathnames[2]), "c.txt"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+
+	if _, err = statePathnames(filepath.Join(dir, "*.missing")); err == nil {
+		t.Errorf("GOT: %v; WANT: %v", err, "non-nil")
+	}
+}
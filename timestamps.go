@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timeLayouts maps the names of commonly encountered timestamp formats to
+// their corresponding time.Parse layout strings. Any layout not found in this
+// map is given to time.Parse verbatim.
+var timeLayouts = map[string]string{
+	"ansic":       time.ANSIC,
+	"clf":         "[02/Jan/2006:15:04:05 -0700]", // common log format, as written by web servers
+	"rfc1123":     time.RFC1123,
+	"rfc1123z":    time.RFC1123Z,
+	"rfc3339":     time.RFC3339,
+	"rfc3339nano": time.RFC3339Nano,
+	"stamp":       time.Stamp, // syslog
+	"unixdate":    time.UnixDate,
+}
+
+// epochScales maps the names of numeric epoch timestamp formats to the number
+// of nanoseconds in each of their units.
+var epochScales = map[string]int64{
+	"unix":      int64(time.Second),
+	"unixmilli": int64(time.Millisecond),
+	"unixmicro": int64(time.Microsecond),
+	"unixnano":  1,
+}
+
+// TimeParser extracts a timestamp from the selected fields of an input line,
+// and parses it according to its configured layout.
+//
+//	func ExampleTimeParser() {
+//	    tp, err := NewTimeParser("1-2", "", "2006-01-02 15:04:05", time.UTC)
+//	    if err != nil {
+//	        panic(err) // for example use
+//	    }
+//	    t, err := tp.Parse("2019-07-04 12:34:56 GET /index.html")
+//	    fmt.Println(t, err)
+//	    // Output: 2019-07-04 12:34:56 +0000 UTC <nil>
+//	}
+type TimeParser struct {
+	fs         *FieldSplitter
+	layout     string         // time.Parse layout; ignored when epochScale is non-zero
+	epochScale int64          // nanoseconds per unit of numeric epoch timestamps
+	location   *time.Location // used when timestamp does not specify its own time zone
+}
+
+// NewTimeParser returns a TimeParser that selects the timestamp from each line
+// using the field specification and field delimiter, then parses it using
+// layout. Layout may be the name of one of the well known timestamp formats,
+// such as "rfc3339", "clf", or "unix", or a time.Parse layout string.
+func NewTimeParser(commaDelimitedSpecs, fieldDelimiter, layout string, location *time.Location) (*TimeParser, error) {
+	if commaDelimitedSpecs == "" {
+		return nil, fmt.Errorf("cannot parse timestamps without a field specification")
+	}
+	fs, err := NewFieldSplitter(commaDelimitedSpecs, fieldDelimiter)
+	if err != nil {
+		return nil, err
+	}
+	tp, err := newValueParser(layout, location)
+	if err != nil {
+		return nil, err
+	}
+	tp.fs = fs
+	return tp, nil
+}
+
+// newValueParser returns a TimeParser without a field specification, which
+// may only parse timestamps already selected from their lines.
+func newValueParser(layout string, location *time.Location) (*TimeParser, error) {
+	tp := &TimeParser{location: location}
+
+	name := strings.ToLower(layout)
+	if scale, ok := epochScales[name]; ok {
+		tp.epochScale = scale
+	} else if l, ok := timeLayouts[name]; ok {
+		tp.layout = l
+	} else if layout == "" {
+		return nil, fmt.Errorf("cannot parse timestamps without a time format")
+	} else {
+		tp.layout = layout
+	}
+	return tp, nil
+}
+
+// Parse returns the time represented by the timestamp fields of the input
+// line.
+func (tp *TimeParser) Parse(line string) (time.Time, error) {
+	value := tp.fs.Select(line)
+	if value == "" {
+		return time.Time{}, fmt.Errorf("cannot find timestamp: %q", line)
+	}
+	return tp.parseValue(value)
+}
+
+// parseValue returns the time represented by a timestamp already selected
+// from its line.
+func (tp *TimeParser) parseValue(value string) (time.Time, error) {
+	if tp.epochScale == 0 {
+		t, err := time.ParseInLocation(tp.layout, value, tp.location)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("cannot parse timestamp: %s", err)
+		}
+		if t.Year() == 0 {
+			// Layouts such as syslog's omit the year, so presume the
+			// timestamp is from the current year.
+			t = t.AddDate(time.Now().In(tp.location).Year(), 0, 0)
+		}
+		return t, nil
+	}
+
+	// Epoch timestamps may have a fractional component, e.g., "1562243696.123".
+	if strings.IndexByte(value, '.') >= 0 {
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("cannot parse timestamp: %s", err)
+		}
+		return time.Unix(0, int64(f*float64(tp.epochScale))).In(tp.location), nil
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("cannot parse timestamp: %s", err)
+	}
+	return time.Unix(0, n*tp.epochScale).In(tp.location), nil
+}
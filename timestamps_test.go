@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func ExampleTimeParser() {
+	tp, err := NewTimeParser("1-2", "", "2006-01-02 15:04:05", time.UTC)
+	if err != nil {
+		panic(err) // for example use
+	}
+	t, err := tp.Parse("2019-07-04 12:34:56 GET /index.html")
+	fmt.Println(t, err)
+	// Output: 2019-07-04 12:34:56 +0000 UTC <nil>
+}
+
+func TestTimeParserRequiresField(t *testing.T) {
+	_, err := NewTimeParser("", "", "rfc3339", time.UTC)
+	if err == nil {
+		t.Errorf("GOT: %v; WANT: %v", err, "non-nil")
+	}
+}
+
+func TestTimeParserNamedLayout(t *testing.T) {
+	tp, err := NewTimeParser("4-5", "", "clf", time.UTC)
+	if err != nil {
+		t.Fatalf("GOT: %v; WANT: %v", err, nil)
+	}
+
+	when, err := tp.Parse(`127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326`)
+	if err != nil {
+		t.Fatalf("GOT: %v; WANT: %v", err, nil)
+	}
+	if got, want := when.UTC(), time.Date(2000, 10, 10, 20, 55, 36, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestTimeParserEpoch(t *testing.T) {
+	tp, err := NewTimeParser("2", ",", "unix", time.UTC)
+	if err != nil {
+		t.Fatalf("GOT: %v; WANT: %v", err, nil)
+	}
+
+	when, err := tp.Parse("abc,1562243696")
+	if err != nil {
+		t.Fatalf("GOT: %v; WANT: %v", err, nil)
+	}
+	if got, want := when, time.Unix(1562243696, 0).UTC(); !got.Equal(want) {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestTimeParserEpochFraction(t *testing.T) {
+	tp, err := NewTimeParser("1", "", "unixmilli", time.UTC)
+	if err != nil {
+		t.Fatalf("GOT: %v; WANT: %v", err, nil)
+	}
+
+	when, err := tp.Parse("1562243696123.5 abc")
+	if err != nil {
+		t.Fatalf("GOT: %v; WANT: %v", err, nil)
+	}
+	if got, want := when.UnixNano()/int64(time.Millisecond), int64(1562243696123); got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestTimeParserMissingField(t *testing.T) {
+	tp, err := NewTimeParser("3", "", "rfc3339", time.UTC)
+	if err != nil {
+		t.Fatalf("GOT: %v; WANT: %v", err, nil)
+	}
+
+	_, err = tp.Parse("one two")
+	if err == nil {
+		t.Errorf("GOT: %v; WANT: %v", err, "non-nil")
+	}
+}
+
+func TestTimeParserInvalid(t *testing.T) {
+	tp, err := NewTimeParser("1", "", "rfc3339", time.UTC)
+	if err != nil {
+		t.Fatalf("GOT: %v; WANT: %v", err, nil)
+	}
+
+	_, err = tp.Parse("yesterday abc")
+	if err == nil {
+		t.Errorf("GOT: %v; WANT: %v", err, "non-nil")
+	}
+}
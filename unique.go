@@ -0,0 +1,51 @@
+package main
+
+// PairSet records which pairs of key and identifier have been seen, in order
+// to count each pair only once. Each distinct key and each distinct
+// identifier is stored once, and each pair is recorded as the combination of
+// their indexes, so memory grows with the number of distinct strings plus
+// eight bytes for each distinct pair, rather than with a copy of both strings
+// for every pair.
+type PairSet struct {
+	keys       map[string]uint32 // index of each distinct key
+	ids        map[string]uint32 // index of each distinct identifier
+	seen       map[uint64]struct{}
+	duplicates int // number of pairs added after they were already seen
+}
+
+// NewPairSet returns an empty PairSet.
+func NewPairSet() *PairSet {
+	return &PairSet{
+		keys: make(map[string]uint32),
+		ids:  make(map[string]uint32),
+		seen: make(map[uint64]struct{}),
+	}
+}
+
+// intern returns the index of s in indexes, adding it when not present.
+func intern(indexes map[string]uint32, s string) uint32 {
+	i, ok := indexes[s]
+	if !ok {
+		i = uint32(len(indexes))
+		indexes[s] = i
+	}
+	return i
+}
+
+// Add records the pair of key and identifier, and returns true unless the
+// pair was already seen.
+func (ps *PairSet) Add(key, id string) bool {
+	pair := uint64(intern(ps.keys, key))<<32 | uint64(intern(ps.ids, id))
+	if _, ok := ps.seen[pair]; ok {
+		ps.duplicates++
+		return false
+	}
+	ps.seen[pair] = struct{}{}
+	return true
+}
+
+// Len returns the number of distinct pairs seen.
+func (ps *PairSet) Len() int { return len(ps.seen) }
+
+// Duplicates returns the number of pairs added after they were already seen.
+func (ps *PairSet) Duplicates() int { return ps.duplicates }
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestPairSet(t *testing.T) {
+	ps := NewPairSet()
+
+	for i, c := range []struct {
+		key, id string
+		want    bool
+	}{
+		{"/a", "alice", true},
+		{"/a", "bob", true},
+		{"/b", "alice", true},
+		{"/a", "alice", false},
+		{"/b", "alice", false},
+		{"/b", "bob", true},
+	} {
+		if got := ps.Add(c.key, c.id); got != c.want {
+			t.Errorf("%d: GOT: %v; WANT: %v", i, got, c.want)
+		}
+	}
+
+	if got, want := ps.Len(), 4; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := ps.Duplicates(), 2; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// boundLayouts are the layouts tried, in order, when parsing an absolute time
+// bound of a TimeWindow.
+var boundLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// TimeWindow is a half open interval of time, including its Since bound but
+// excluding its Until bound. A zero bound leaves that side of the window
+// unbounded.
+type TimeWindow struct {
+	Since, Until time.Time
+}
+
+// IsZero returns true when neither side of the window is bounded.
+func (w TimeWindow) IsZero() bool {
+	return w.Since.IsZero() && w.Until.IsZero()
+}
+
+// Contains returns true when t falls within the window.
+func (w TimeWindow) Contains(t time.Time) bool {
+	if !w.Since.IsZero() && t.Before(w.Since) {
+		return false
+	}
+	return !w.Ended(t)
+}
+
+// Ended returns true when t is at or after the Until bound of the window.
+func (w TimeWindow) Ended(t time.Time) bool {
+	return !w.Until.IsZero() && !t.Before(w.Until)
+}
+
+// ParseTimeBound returns the time represented by s, which is either relative
+// to now, such as "-2h" or "now", or an absolute time. Absolute times are
+// tried against several common layouts, then finally against layout, which is
+// normally the layout of the timestamps being filtered, including numeric
+// epoch formats such as "unix". Absolute times without a time zone are
+// interpreted in location.
+func ParseTimeBound(s string, now time.Time, layout string, location *time.Location) (time.Time, error) {
+	if s == "now" {
+		return now, nil
+	}
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("cannot parse relative time: %s", err)
+		}
+		return now.Add(d), nil
+	}
+	for _, l := range boundLayouts {
+		if t, err := time.ParseInLocation(l, s, location); err == nil {
+			return t, nil
+		}
+	}
+	tp, err := newValueParser(layout, location)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("cannot parse time: %q", s)
+	}
+	t, err := tp.parseValue(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("cannot parse time: %q", s)
+	}
+	return t, nil
+}
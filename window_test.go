@@ -0,0 +1,103 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimeBoundRelative(t *testing.T) {
+	now := time.Date(2019, 7, 4, 12, 0, 0, 0, time.UTC)
+
+	got, err := ParseTimeBound("-2h", now, "rfc3339", time.UTC)
+	if err != nil {
+		t.Fatalf("GOT: %v; WANT: %v", err, nil)
+	}
+	if want := now.Add(-2 * time.Hour); !got.Equal(want) {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+
+	got, err = ParseTimeBound("now", now, "rfc3339", time.UTC)
+	if err != nil {
+		t.Fatalf("GOT: %v; WANT: %v", err, nil)
+	}
+	if want := now; !got.Equal(want) {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestParseTimeBoundAbsolute(t *testing.T) {
+	now := time.Date(2019, 7, 4, 12, 0, 0, 0, time.UTC)
+
+	got, err := ParseTimeBound("2019-07-01 08:30", now, "rfc3339", time.UTC)
+	if err != nil {
+		t.Fatalf("GOT: %v; WANT: %v", err, nil)
+	}
+	if want := time.Date(2019, 7, 1, 8, 30, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestParseTimeBoundInputLayout(t *testing.T) {
+	now := time.Date(2019, 7, 4, 12, 0, 0, 0, time.UTC)
+
+	got, err := ParseTimeBound("[10/Oct/2000:13:55:36 -0700]", now, "clf", time.UTC)
+	if err != nil {
+		t.Fatalf("GOT: %v; WANT: %v", err, nil)
+	}
+	if want := time.Date(2000, 10, 10, 20, 55, 36, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestParseTimeBoundEpochLayout(t *testing.T) {
+	now := time.Date(2019, 7, 4, 12, 0, 0, 0, time.UTC)
+
+	got, err := ParseTimeBound("1562243700", now, "unix", time.UTC)
+	if err != nil {
+		t.Fatalf("GOT: %v; WANT: %v", err, nil)
+	}
+	if want := time.Unix(1562243700, 0); !got.Equal(want) {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+
+	got, err = ParseTimeBound("1562243700123", now, "unixmilli", time.UTC)
+	if err != nil {
+		t.Fatalf("GOT: %v; WANT: %v", err, nil)
+	}
+	if want := time.Unix(1562243700, 123e6); !got.Equal(want) {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestParseTimeBoundInvalid(t *testing.T) {
+	now := time.Date(2019, 7, 4, 12, 0, 0, 0, time.UTC)
+
+	if _, err := ParseTimeBound("-2 hours", now, "rfc3339", time.UTC); err == nil {
+		t.Errorf("GOT: %v; WANT: %v", err, "non-nil")
+	}
+	if _, err := ParseTimeBound("yesterday", now, "rfc3339", time.UTC); err == nil {
+		t.Errorf("GOT: %v; WANT: %v", err, "non-nil")
+	}
+}
+
+func TestTimeWindow(t *testing.T) {
+	since := time.Date(2019, 7, 4, 12, 0, 0, 0, time.UTC)
+	until := since.Add(time.Hour)
+	w := TimeWindow{Since: since, Until: until}
+
+	if got, want := w.Contains(since.Add(-time.Second)), false; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := w.Contains(since), true; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := w.Contains(until), false; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := w.Ended(until), true; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := (TimeWindow{}).Contains(until), true; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}